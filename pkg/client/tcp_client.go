@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -26,7 +27,7 @@ func New(cfg config.ChannelConfig, spec *iso8583.Spec) *IsoClient {
 
 // Connect handles the TCP handshake and keeps retrying if it fails
 func (c *IsoClient) Connect() error {
-	address := fmt.Sprintf("%s:%d", c.Config.IP, c.Config.Port)
+	address := net.JoinHostPort(c.Config.IP, strconv.Itoa(c.Config.Port))
 
 	for {
 		conn, err := net.DialTimeout("tcp", address, 5*time.Second)
@@ -65,7 +66,7 @@ func (c *IsoClient) Send(msg *iso8583.Message) error {
 // SendAndReceive sends a request and waits for the specific response
 func (c *IsoClient) SendAndReceive(req *iso8583.Message, timeout time.Duration) (*iso8583.Message, error) {
 	// 1. Extract the Correlation Key (STAN - Field 11)
-	stan := string(req.Fields[11].Value)
+	stan := req.Get(11)
 
 	// 2. Create a channel to receive the response
 	respChan := make(chan *iso8583.Message, 1)
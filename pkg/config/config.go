@@ -7,22 +7,69 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig    `yaml:"server"`
-	Channels []ChannelConfig `yaml:"channels"`
+	Server    ServerConfig     `yaml:"server"`
+	Listeners []ListenerConfig `yaml:"listeners"`
+	Channels  []ChannelConfig  `yaml:"channels"`
+	Framings  []FramingConfig  `yaml:"framings"`
 }
 
 type ServerConfig struct {
 	Port        int    `yaml:"port"`
 	IP          string `yaml:"ip"`
 	ReadTimeout int    `yaml:"read_timeout"`
+	Channel     string `yaml:"channel"` // Channel type: NAC (default), NCC, BCD, BASE24 or a framing name
+	Debug       bool   `yaml:"debug"`   // Log an annotated dump of every frame at debug level
 }
 
+// ListenerConfig defines one of several server listeners; without any, the
+// engine listens on the server section alone
+type ListenerConfig struct {
+	Name        string `yaml:"name"`
+	IP          string `yaml:"ip"`
+	Port        int    `yaml:"port"`
+	Channel     string `yaml:"channel"`      // Channel type, as server.channel
+	Spec        string `yaml:"spec"`         // Spec file, when not the server's
+	Header      string `yaml:"header"`       // Header/TPDU in hex, replacing the framing's
+	ReadTimeout int    `yaml:"read_timeout"` // Seconds a connection may stay silent; 0 for no limit
+	Handler     string `yaml:"handler"`      // Handler name (see Engine.Handle); the Request handler by default
+	QueueSize   int    `yaml:"queue_size"`   // Outbound frames queued per connection; 0 writes from the handlers
+	Debug       bool   `yaml:"debug"`        // Log an annotated dump of every frame at debug level
+}
+
+// ChannelConfig defines an outgoing peer
 type ChannelConfig struct {
-	Name              string `yaml:"name"`
-	IP                string `yaml:"ip"`
-	Port              int    `yaml:"port"`
-	Enabled           bool   `yaml:"enabled"`
-	ReconnectInterval int    `yaml:"reconnect_interval"`
+	Name                 string `yaml:"name"`
+	IP                   string `yaml:"ip"`
+	Port                 int    `yaml:"port"`
+	Enabled              bool   `yaml:"enabled"`
+	ReconnectInterval    int    `yaml:"reconnect_interval"`     // First retry delay in seconds, doubled on every failure
+	MaxReconnectInterval int    `yaml:"max_reconnect_interval"` // Retry delay cap in seconds
+	Type                 string `yaml:"type"`                   // Channel type, as server.channel
+	Spec                 string `yaml:"spec"`                   // Spec file, when not the server's
+	Header               string `yaml:"header"`                 // Header/TPDU in hex, replacing the framing's
+	QueueSize            int    `yaml:"queue_size"`             // Outbound frames queued; 0 writes from the senders
+	Debug                bool   `yaml:"debug"`                  // Log an annotated dump of every frame at debug level
+}
+
+// FramingConfig defines a channel type by its framing:
+// [length][header][ISO message][trailer]
+type FramingConfig struct {
+	Name            string       `yaml:"name"`
+	Length          LengthConfig `yaml:"length"`
+	Inclusive       bool         `yaml:"inclusive"`         // Length counts its own bytes
+	Header          string       `yaml:"header"`            // Fixed header in hex (e.g., TPDU "6000000000")
+	SwapTPDU        bool         `yaml:"swap_tpdu"`         // Swap TPDU source and destination on replies
+	Trailer         string       `yaml:"trailer"`           // Trailer in hex (e.g., "03")
+	TrailerInLength bool         `yaml:"trailer_in_length"` // Length counts the trailer
+	KeepAlive       bool         `yaml:"keep_alive"`        // Echo zero-length frames
+	Debug           bool         `yaml:"debug"`             // Log an annotated dump of every frame at debug level
+}
+
+// LengthConfig is the frame length header, as field prefixes in spec files
+type LengthConfig struct {
+	Type   string `yaml:"type"`   // ascii, bcd, ebcdic or binary
+	Digits int    `yaml:"digits"` // ascii, bcd and ebcdic
+	Bytes  int    `yaml:"bytes"`  // binary
 }
 
 func LoadAppConfig(path string) (*Config, error) {
@@ -35,6 +82,9 @@ func LoadAppConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	if cfg.Server.Channel == "" {
+		cfg.Server.Channel = "NAC"
+	}
 
 	return &cfg, nil
 }
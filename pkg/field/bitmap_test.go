@@ -0,0 +1,79 @@
+package field
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHexBitmapsTertiary(t *testing.T) {
+	fields := map[int]bool{2: true, 70: true, 192: true}
+
+	for _, bm := range []BitMap{&FABitmap{Tertiary: true}, &FEBitmap{Tertiary: true}} {
+		packed, err := bm.Pack(fields)
+		if err != nil {
+			t.Fatalf("%T Pack failed: %v", bm, err)
+		}
+		if len(packed) != 48 {
+			t.Fatalf("%T: got %d bytes", bm, len(packed))
+		}
+
+		got, readLen, err := bm.Unpack(append(packed, '0'))
+		if err != nil {
+			t.Fatalf("%T Unpack failed: %v", bm, err)
+		}
+		if readLen != 48 || !got[2] || !got[70] || !got[192] {
+			t.Errorf("%T: readLen=%d fields=%v", bm, readLen, got)
+		}
+	}
+
+	ascii, _ := (&FABitmap{Tertiary: true}).Pack(fields)
+	want := []byte("C000000000000000" + "8400000000000000" + "0000000000000001")
+	if !bytes.Equal(ascii, want) {
+		t.Errorf("FABitmap: got %s", ascii)
+	}
+
+	// Without tertiary support bit 65 is an ordinary field
+	got, readLen, err := (&FABitmap{}).Unpack(want)
+	if err != nil || readLen != 32 || !got[65] {
+		t.Errorf("FABitmap without tertiary: readLen=%d fields=%v err=%v", readLen, got, err)
+	}
+	if _, err := (&FABitmap{}).Pack(fields); err == nil {
+		t.Error("expected error for field 192 without tertiary bitmap")
+	}
+}
+
+func TestBits(t *testing.T) {
+	var b Bits
+	for _, n := range []int{2, 64, 65, 128, 192} {
+		b.Set(n)
+	}
+	var got []int
+	for n := b.Next(0); n != 0; n = b.Next(n) {
+		got = append(got, n)
+	}
+	if len(got) != 5 || got[0] != 2 || got[1] != 64 || got[2] != 65 || got[3] != 128 || got[4] != 192 {
+		t.Errorf("Next walked %v", got)
+	}
+	b.Unset(65)
+	if b.Has(65) || !b.Has(64) || b.Has(0) || b.Has(193) {
+		t.Errorf("unexpected membership: %v", b.Map())
+	}
+
+	// The map and Bits forms of every bitmap agree
+	fields := map[int]bool{3: true, 70: true, 150: true}
+	for _, bm := range []BitMap{&FBBitmap{Tertiary: true}, &FABitmap{Tertiary: true}, &FEBitmap{Tertiary: true}} {
+		var set Bits
+		for n := range fields {
+			set.Set(n)
+		}
+		want, _ := bm.Pack(fields)
+		got, err := bm.(BitmapAppender).AppendBits([]byte("x"), set)
+		if err != nil || !bytes.Equal(got[1:], want) {
+			t.Errorf("%T AppendBits = % X, %v; want % X", bm, got, err, want)
+		}
+		decoded, n, err := bm.(BitmapAppender).UnpackBits(want)
+		if err != nil || n != len(want) || !decoded.Has(3) || !decoded.Has(70) || !decoded.Has(150) || !decoded.Has(65) {
+			t.Errorf("%T UnpackBits = %v, %d, %v", bm, decoded.Map(), n, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package field
+
+import (
+	"math/bits"
+)
+
+// Bits is a set of field numbers (1-192) held in a fixed array, word i
+// covering fields 64*i+1 to 64*i+64 with the lowest field in the top bit,
+// which is also the wire order of a binary bitmap
+type Bits [3]uint64
+
+// Set adds field n; it panics outside 1-192
+func (b *Bits) Set(n int) {
+	b[(n-1)/64] |= 1 << (63 - uint(n-1)%64)
+}
+
+// Unset removes field n
+func (b *Bits) Unset(n int) {
+	b[(n-1)/64] &^= 1 << (63 - uint(n-1)%64)
+}
+
+// Has reports whether field n is set; numbers outside 1-192 never are
+func (b *Bits) Has(n int) bool {
+	if n < 1 || n > 192 {
+		return false
+	}
+	return b[(n-1)/64]&(1<<(63-uint(n-1)%64)) != 0
+}
+
+// Next returns the lowest field set after n, or 0 if there is none
+func (b *Bits) Next(n int) int {
+	for n < 192 {
+		w := n / 64
+		rest := b[w] << (uint(n) % 64)
+		if rest != 0 {
+			return n + bits.LeadingZeros64(rest) + 1
+		}
+		n = (w + 1) * 64
+	}
+	return 0
+}
+
+// Map converts the set to the map form of BitMap
+func (b *Bits) Map() map[int]bool {
+	res := make(map[int]bool)
+	for n := b.Next(0); n != 0; n = b.Next(n) {
+		res[n] = true
+	}
+	return res
+}
+
+// BitmapAppender is implemented by bitmaps that work on Bits, appending to a
+// caller's buffer, so that packing and unpacking a message allocates no map.
+// All built-in bitmaps implement it.
+type BitmapAppender interface {
+	AppendBits(dst []byte, fields Bits) ([]byte, error)
+	UnpackBits(data []byte) (Bits, int, error)
+}
@@ -0,0 +1,78 @@
+package field
+
+import (
+	"fmt"
+)
+
+// Class is an ISO 8583 content class, describing which characters a field value may hold
+type Class string
+
+const (
+	ClassNumeric             Class = "n"   // Digits
+	ClassAlpha               Class = "a"   // Letters and spaces
+	ClassAlphanumeric        Class = "an"  // Letters, digits and spaces
+	ClassAlphanumericSpecial Class = "ans" // Printable ASCII
+	ClassBinary              Class = "b"   // Hex string of the raw bytes
+	ClassTrack               Class = "z"   // Track 2/3 data: digits, separators and sentinels
+	ClassSignedAmount        Class = "x+n" // 'C' (credit) or 'D' (debit) followed by digits
+)
+
+// ParseClass checks a class name; the empty string means unchecked
+func ParseClass(s string) (Class, error) {
+	switch c := Class(s); c {
+	case "", ClassNumeric, ClassAlpha, ClassAlphanumeric, ClassAlphanumericSpecial,
+		ClassBinary, ClassTrack, ClassSignedAmount:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown content class: %s", s)
+}
+
+// Validate reports the first character of val that the class does not allow
+func (c Class) Validate(val string) error {
+	switch c {
+	case "":
+		return nil
+	case ClassBinary:
+		if len(val)%2 != 0 {
+			return fmt.Errorf("binary value has odd hex length %d", len(val))
+		}
+	case ClassSignedAmount:
+		if len(val) < 2 || (val[0] != 'C' && val[0] != 'D') {
+			return fmt.Errorf("signed amount must start with C or D")
+		}
+		return checkChars(ClassNumeric, val[1:], 1)
+	}
+	return checkChars(c, val, 0)
+}
+
+func checkChars(c Class, val string, offset int) error {
+	for i := 0; i < len(val); i++ {
+		if !c.allows(val[i]) {
+			return fmt.Errorf("character %q at position %d not allowed in class %s", val[i], i+offset, c)
+		}
+	}
+	return nil
+}
+
+func (c Class) allows(ch byte) bool {
+	isDigit := ch >= '0' && ch <= '9'
+	isLetter := (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z')
+
+	switch c {
+	case ClassNumeric:
+		return isDigit
+	case ClassAlpha:
+		return isLetter || ch == ' '
+	case ClassAlphanumeric:
+		return isLetter || isDigit || ch == ' '
+	case ClassAlphanumericSpecial:
+		return ch >= 0x20 && ch <= 0x7E
+	case ClassBinary:
+		return isDigit || (ch >= 'A' && ch <= 'F') || (ch >= 'a' && ch <= 'f')
+	case ClassTrack:
+		// '=' or 'D' separate PAN and expiry ('d' as BCD unpacks it), ';'
+		// and '?' are sentinels, 'F' pads odd-length BCD track data
+		return isDigit || ch == '=' || ch == 'D' || ch == 'd' || ch == ';' || ch == '?' || ch == 'F'
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package field
+
+import "testing"
+
+func TestClassValidate(t *testing.T) {
+	tests := []struct {
+		class Class
+		val   string
+		ok    bool
+	}{
+		{ClassNumeric, "000123", true},
+		{ClassNumeric, "12A", false},
+		{ClassAlpha, "ABC def", true},
+		{ClassAlpha, "AB1", false},
+		{ClassAlphanumeric, "TERM 01", true},
+		{ClassAlphanumeric, "TERM-01", false},
+		{ClassAlphanumericSpecial, "TERM-01/#", true},
+		{ClassAlphanumericSpecial, "A\x00", false},
+		{ClassBinary, "9F27", true},
+		{ClassBinary, "9F2", false},
+		{ClassBinary, "9G", false},
+		{ClassTrack, "4111111111111111=2512101", true},
+		{ClassTrack, "4111111111111111D2512101F", true},
+		{ClassTrack, "4111111111111111d2512101", true},
+		{ClassTrack, "4111^", false},
+		{ClassSignedAmount, "C00000100", true},
+		{ClassSignedAmount, "00000100", false},
+		{ClassSignedAmount, "D0001X", false},
+		{"", "anything \x00", true},
+	}
+
+	for _, tt := range tests {
+		err := tt.class.Validate(tt.val)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s %q: got error %v, want ok=%v", tt.class, tt.val, err, tt.ok)
+		}
+	}
+
+	if _, err := ParseClass("nn"); err == nil {
+		t.Error("expected error for unknown class")
+	}
+}
+
+// BCD encoders take what fits in a nibble, including the Track 2 separator;
+// character classes are checked by FieldSpec.Class in strict mode
+func TestFBNumericRejectsNonBCD(t *testing.T) {
+	if _, err := (&FBNumeric{}).Pack("12A4", 4); err == nil {
+		t.Error("expected error for non-BCD value")
+	}
+	if _, err := (&FBNumeric{}).Pack("12345", 4); err == nil {
+		t.Error("expected error for oversized BCD value")
+	}
+	if _, err := (&FBLLNumeric{}).Pack("41X1", 19); err == nil {
+		t.Error("expected error for non-BCD LL value")
+	}
+	if _, err := (&FBNumeric{}).Pack("12D4", 4); err != nil {
+		t.Errorf("separator nibble rejected: %v", err)
+	}
+}
+
+func TestFBLLNumericTrack2(t *testing.T) {
+	enc := &FBLLNumeric{}
+	for _, track := range []string{"4111111111111111D2512101", "4111111111111111=2512101"} {
+		packed, err := enc.Pack(track, 37)
+		if err != nil {
+			t.Fatalf("%s: %v", track, err)
+		}
+		want := []byte{0x24, 0x41, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0xD2, 0x51, 0x21, 0x01}
+		if string(packed) != string(want) {
+			t.Errorf("%s: packed % X, want % X", track, packed, want)
+		}
+
+		val, n, err := enc.Unpack(packed, 37)
+		if err != nil || n != len(packed) {
+			t.Fatalf("%s: unpack = %q, %d, %v", track, val, n, err)
+		}
+		if val != "4111111111111111d2512101" {
+			t.Errorf("%s: unpacked %q", track, val)
+		}
+		if err := ClassTrack.Validate(val); err != nil {
+			t.Errorf("%s: unpacked value fails class z: %v", track, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package field
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFEEncodersRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder ISOField
+		length  int
+		value   string
+		want    []byte
+		decoded string
+	}{
+		{"FEChar pads", &FEChar{}, 6, "ABC", []byte{0xC1, 0xC2, 0xC3, 0x40, 0x40, 0x40}, "ABC   "},
+		{"FEChar mixed", &FEChar{}, 5, "a1-Z.", []byte{0x81, 0xF1, 0x60, 0xE9, 0x4B}, "a1-Z."},
+		{"FENumeric", &FENumeric{}, 6, "1234", []byte{0xF0, 0xF0, 0xF1, 0xF2, 0xF3, 0xF4}, "001234"},
+		{"FELLChar", &FELLChar{}, 25, "HELLO", []byte{0xF0, 0xF5, 0xC8, 0xC5, 0xD3, 0xD3, 0xD6}, "HELLO"},
+		{"FELLLChar", &FELLLChar{}, 999, "OK", []byte{0xF0, 0xF0, 0xF2, 0xD6, 0xD2}, "OK"},
+		{"FELLNumeric", &FELLNumeric{}, 11, "123456", []byte{0xF0, 0xF6, 0xF1, 0xF2, 0xF3, 0xF4, 0xF5, 0xF6}, "123456"},
+		{"FEChar CP1047", &FEChar{CodePage: CP1047}, 3, "[^]", []byte{0xAD, 0x5F, 0xBD}, "[^]"},
+		{"FEChar CP037", &FEChar{}, 3, "[^]", []byte{0xBA, 0xB0, 0xBB}, "[^]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packed, err := tt.encoder.Pack(tt.value, tt.length)
+			if err != nil {
+				t.Fatalf("Pack failed: %v", err)
+			}
+			if !bytes.Equal(packed, tt.want) {
+				t.Fatalf("Pack: got % X, want % X", packed, tt.want)
+			}
+
+			val, readLen, err := tt.encoder.Unpack(append(packed, 0xFF), tt.length)
+			if err != nil {
+				t.Fatalf("Unpack failed: %v", err)
+			}
+			if val != tt.decoded || readLen != len(tt.want) {
+				t.Errorf("Unpack: got %q (%d bytes), want %q (%d bytes)", val, readLen, tt.decoded, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFELLCharRejectsBadHeader(t *testing.T) {
+	if _, _, err := (&FELLChar{}).Unpack([]byte{0x60, 0xF1, 0xC1}, 99); err == nil {
+		t.Error("expected error for negative length header")
+	}
+	if _, _, err := (&FELLChar{}).Unpack([]byte{0xF0, 0xF5, 0xC1}, 99); err == nil {
+		t.Error("expected error for short content")
+	}
+}
+
+func TestFEBitmap(t *testing.T) {
+	bm := &FEBitmap{}
+	packed, err := bm.Pack(map[int]bool{3: true, 11: true})
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	// "2020000000000000" in EBCDIC
+	want := []byte{0xF2, 0xF0, 0xF2, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0, 0xF0}
+	if !bytes.Equal(packed, want) {
+		t.Fatalf("Pack: got % X", packed)
+	}
+
+	fields, readLen, err := bm.Unpack(packed)
+	if err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if readLen != 16 || !fields[3] || !fields[11] || len(fields) != 2 {
+		t.Errorf("readLen=%d fields=%v", readLen, fields)
+	}
+
+	packed, _ = bm.Pack(map[int]bool{2: true, 128: true})
+	fields, readLen, err = bm.Unpack(packed)
+	if err != nil {
+		t.Fatalf("Unpack secondary failed: %v", err)
+	}
+	if readLen != 32 || !fields[2] || !fields[128] {
+		t.Errorf("readLen=%d fields=%v", readLen, fields)
+	}
+}
@@ -2,21 +2,31 @@ package field
 
 import (
 	"fmt"
-	"strings"
 )
 
 type FChar struct{}
 
+func init() {
+	Register("FChar", func() ISOField {
+		return &FChar{}
+	})
+}
+
 // Pack pads the string with spaces on the right to reach the fixed length
 func (f *FChar) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FChar) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	if len(val) > length {
 		// Most hosts truncate, but returning an error is safer for a framework
 		val = val[:length]
 	}
 
 	// Right pad with spaces (e.g., "ABC" length 5 -> "ABC  ")
-	padded := val + strings.Repeat(" ", length-len(val))
-	return []byte(padded), nil
+	dst = append(dst, val...)
+	return appendRepeat(dst, ' ', length-len(val)), nil
 }
 
 // Unpack reads exactly 'length' bytes from the data
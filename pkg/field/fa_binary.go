@@ -3,18 +3,40 @@ package field
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type FABinary struct{}
 
+func init() {
+	Register("FABinary", func() ISOField {
+		return &FABinary{}
+	})
+}
+
 func (f *FABinary) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FABinary) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	// In ASCII mode, binary data is usually passed as a hex string
 	// Ensure it is uppercase and padded/truncated to fixed length
 	if len(val) > length {
 		val = val[:length]
 	}
-	padded := val + strings.Repeat("0", length-len(val))
-	return []byte(strings.ToUpper(padded)), nil
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c >= utf8.RuneSelf {
+			// Leave non-ASCII input to the Unicode rules
+			return append(dst, strings.ToUpper(val+strings.Repeat("0", length-len(val)))...), nil
+		}
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return appendRepeat(dst, '0', length-len(val)), nil
 }
 
 func (f *FABinary) Unpack(data []byte, length int) (string, int, error) {
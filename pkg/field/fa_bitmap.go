@@ -1,25 +1,47 @@
 package field
 
 import (
-	"fmt"
+	"encoding/hex"
 	"strings"
 )
 
-type FABitmap struct{}
+// FABitmap carries the bitmap as uppercase hex ASCII (16, 32 or 48 characters)
+type FABitmap struct {
+	Tertiary bool
+}
+
+func init() {
+	RegisterBitmap("FABitmap", func(tertiary bool) BitMap {
+		return &FABitmap{Tertiary: tertiary}
+	})
+}
+
+// MaxField returns the highest field number this bitmap can carry
+func (f *FABitmap) MaxField() int {
+	return maxBitmapField(f.Tertiary)
+}
 
-func (f *FABitmap) Pack(val string, length int) ([]byte, error) {
-	// val is expected to be the hex string representation
-	// length here is usually 16 (primary) or 32 (secondary)
-	if len(val) < length {
-		val = val + strings.Repeat("0", length-len(val))
+func (f *FABitmap) Pack(fields map[int]bool) ([]byte, error) {
+	// Build the binary form first, then expand each byte to two hex characters
+	raw, err := (&FBBitmap{Tertiary: f.Tertiary}).Pack(fields)
+	if err != nil {
+		return nil, err
 	}
-	return []byte(strings.ToUpper(val)), nil
+	return []byte(strings.ToUpper(hex.EncodeToString(raw))), nil
 }
 
-func (f *FABitmap) Unpack(data []byte, length int) (string, int, error) {
-	// For ASCII, we read 'length' characters (e.g., 16 or 32)
-	if len(data) < length {
-		return "", 0, fmt.Errorf("insufficient data for FA_Bitmap")
+func (f *FABitmap) Unpack(data []byte) (map[int]bool, int, error) {
+	set, readLen, err := f.UnpackBits(data)
+	if err != nil {
+		return nil, 0, err
 	}
-	return string(data[:length]), length, nil
+	return set.Map(), readLen, nil
+}
+
+func (f *FABitmap) AppendBits(dst []byte, fields Bits) ([]byte, error) {
+	return appendHexBitmap(dst, fields, f.Tertiary)
+}
+
+func (f *FABitmap) UnpackBits(data []byte) (Bits, int, error) {
+	return unpackHexBitmap(data, f.Tertiary, "FA_Bitmap", nil)
 }
@@ -7,15 +7,26 @@ import (
 
 type FALLChar struct{}
 
+func init() {
+	Register("FALLChar", func() ISOField {
+		return &FALLChar{}
+	})
+}
+
 func (f *FALLChar) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FALLChar) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
 	}
 
 	// 2-byte ASCII header (e.g., "05")
-	header := fmt.Sprintf("%02d", dataLen)
-	return append([]byte(header), []byte(val)...), nil
+	dst = appendDecimal(dst, dataLen, 2)
+	return append(dst, val...), nil
 }
 
 func (f *FALLChar) Unpack(data []byte, length int) (string, int, error) {
@@ -35,3 +46,13 @@ func (f *FALLChar) Unpack(data []byte, length int) (string, int, error) {
 
 	return string(data[2 : 2+dataLen]), 2 + dataLen, nil
 }
+
+// MaxLength is the largest length the ASCII LL header can express
+func (f *FALLChar) MaxLength() int {
+	return 99
+}
+
+// HeaderLen is the size in bytes of the ASCII LL header
+func (f *FALLChar) HeaderLen() int {
+	return 2
+}
@@ -7,15 +7,26 @@ import (
 
 type FALLLChar struct{}
 
+func init() {
+	Register("FALLLChar", func() ISOField {
+		return &FALLLChar{}
+	})
+}
+
 func (f *FALLLChar) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FALLLChar) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
 	}
 
 	// 3-byte ASCII header (e.g., "045" for 45 bytes)
-	header := fmt.Sprintf("%03d", dataLen)
-	return append([]byte(header), []byte(val)...), nil
+	dst = appendDecimal(dst, dataLen, 3)
+	return append(dst, val...), nil
 }
 
 func (f *FALLLChar) Unpack(data []byte, length int) (string, int, error) {
@@ -35,3 +46,13 @@ func (f *FALLLChar) Unpack(data []byte, length int) (string, int, error) {
 
 	return string(data[3 : 3+dataLen]), 3 + dataLen, nil
 }
+
+// MaxLength is the largest length the ASCII LLL header can express
+func (f *FALLLChar) MaxLength() int {
+	return 999
+}
+
+// HeaderLen is the size in bytes of the ASCII LLL header
+func (f *FALLLChar) HeaderLen() int {
+	return 3
+}
@@ -7,15 +7,26 @@ import (
 
 type FALLNumeric struct{}
 
+func init() {
+	Register("FALLNumeric", func() ISOField {
+		return &FALLNumeric{}
+	})
+}
+
 func (f *FALLNumeric) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FALLNumeric) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
 	}
 
 	// ASCII Header (e.g., "06") + Data ("123456")
-	header := fmt.Sprintf("%02d", dataLen)
-	return []byte(header + val), nil
+	dst = appendDecimal(dst, dataLen, 2)
+	return append(dst, val...), nil
 }
 
 func (f *FALLNumeric) Unpack(data []byte, length int) (string, int, error) {
@@ -35,3 +46,13 @@ func (f *FALLNumeric) Unpack(data []byte, length int) (string, int, error) {
 
 	return string(data[2 : 2+dataLen]), 2 + dataLen, nil
 }
+
+// MaxLength is the largest length the ASCII LL header can express
+func (f *FALLNumeric) MaxLength() int {
+	return 99
+}
+
+// HeaderLen is the size in bytes of the ASCII LL header
+func (f *FALLNumeric) HeaderLen() int {
+	return 2
+}
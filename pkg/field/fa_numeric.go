@@ -2,18 +2,28 @@ package field
 
 import (
 	"fmt"
-	"strings"
 )
 
 type FANumeric struct{}
 
+func init() {
+	Register("FANumeric", func() ISOField {
+		return &FANumeric{}
+	})
+}
+
 func (f *FANumeric) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FANumeric) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	if len(val) > length {
 		val = val[:length] // Or return error
 	}
 	// Pad left with '0'
-	padded := strings.Repeat("0", length-len(val)) + val
-	return []byte(padded), nil
+	dst = appendRepeat(dst, '0', length-len(val))
+	return append(dst, val...), nil
 }
 
 func (f *FANumeric) Unpack(data []byte, length int) (string, int, error) {
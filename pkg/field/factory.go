@@ -0,0 +1,56 @@
+package field
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Constructor defines a function signature that creates a field encoder
+type Constructor func() ISOField
+
+// BitmapConstructor defines a function signature that creates a bitmap encoder,
+// optionally able to carry a tertiary bitmap (fields 129-192)
+type BitmapConstructor func(tertiary bool) BitMap
+
+var (
+	registry       = make(map[string]Constructor)
+	bitmapRegistry = make(map[string]BitmapConstructor)
+)
+
+// Register allows you to add new encoder types from anywhere in your app
+func Register(name string, fn Constructor) {
+	registry[name] = fn
+}
+
+// New creates the specific encoder based on the spec name (e.g., "FBLLNumeric")
+func New(name string) (ISOField, error) {
+	constructor, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field encoder: %s", name)
+	}
+	return constructor(), nil
+}
+
+// RegisterBitmap adds a new bitmap encoder type
+func RegisterBitmap(name string, fn BitmapConstructor) {
+	bitmapRegistry[name] = fn
+}
+
+// NewBitmap creates the specific bitmap encoder based on the spec name (e.g., "FABitmap")
+func NewBitmap(name string, tertiary bool) (BitMap, error) {
+	constructor, ok := bitmapRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown bitmap encoder: %s", name)
+	}
+	return constructor(tertiary), nil
+}
+
+// Names returns the sorted list of registered encoder names
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -8,27 +8,33 @@ import (
 
 type FBBinary struct{}
 
+func init() {
+	Register("FBBinary", func() ISOField {
+		return &FBBinary{}
+	})
+}
+
 func (f *FBBinary) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FBBinary) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	// The input 'val' is expected to be a Hex string representation of the bytes
-	b, err := hex.DecodeString(val)
+	start := len(dst)
+	dst, err := hex.AppendDecode(dst, []byte(val))
 	if err != nil {
 		return nil, fmt.Errorf("invalid hex string for FB_Binary: %v", err)
 	}
 
 	// In raw binary, the byte length is usually half the hex string length
 	expectedByteLen := length / 2
-	if len(b) > expectedByteLen {
-		b = b[:expectedByteLen]
+	if len(dst)-start > expectedByteLen {
+		dst = dst[:start+expectedByteLen]
 	}
 
 	// Pad with null bytes if necessary
-	if len(b) < expectedByteLen {
-		padded := make([]byte, expectedByteLen)
-		copy(padded, b)
-		return padded, nil
-	}
-
-	return b, nil
+	return appendRepeat(dst, 0, start+expectedByteLen-len(dst)), nil
 }
 
 func (f *FBBinary) Unpack(data []byte, length int) (string, int, error) {
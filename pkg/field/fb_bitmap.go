@@ -1,63 +1,169 @@
 package field
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 )
 
-type FBBitmap struct{}
+// FBBitmap is the binary bitmap: 8 bytes, 16 with a secondary bitmap (bit 1),
+// and 24 with a tertiary bitmap (bit 65) when Tertiary is enabled
+type FBBitmap struct {
+	Tertiary bool
+}
+
+func init() {
+	RegisterBitmap("FBBitmap", func(tertiary bool) BitMap {
+		return &FBBitmap{Tertiary: tertiary}
+	})
+}
+
+// MaxField returns the highest field number this bitmap can carry
+func (b *FBBitmap) MaxField() int {
+	return maxBitmapField(b.Tertiary)
+}
 
 func (b *FBBitmap) Pack(fields map[int]bool) ([]byte, error) {
-	// Determine size (8 or 16 bytes)
-	size := 8
-	for f := range fields {
-		if f > 64 {
-			size = 16
-			break
+	var set Bits
+	for f, present := range fields {
+		if !present || f <= 1 {
+			continue
+		}
+		if f > b.MaxField() {
+			return nil, fmt.Errorf("field %d exceeds bitmap capacity of %d fields", f, b.MaxField())
 		}
+		set.Set(f)
 	}
+	return b.AppendBits(nil, set)
+}
 
-	res := make([]byte, size)
-	if size == 16 {
-		res[0] |= 0x80 // Set Bit 1 for secondary
+// AppendBits appends the bitmap of fields to dst; bits 1 and 65 are
+// computed from the fields present rather than taken from the set
+func (b *FBBitmap) AppendBits(dst []byte, fields Bits) ([]byte, error) {
+	set := fields
+	set.Unset(1)
+	if !b.Tertiary && set[2] != 0 {
+		return nil, fmt.Errorf("field %d exceeds bitmap capacity of %d fields", set.Next(128), b.MaxField())
 	}
-
-	for f, present := range fields {
-		if !present || f <= 1 || f > 128 {
-			continue
+	if b.Tertiary {
+		if set.Has(65) {
+			return nil, fmt.Errorf("field 65 is reserved for the tertiary bitmap indicator")
+		}
+		if set[2] != 0 {
+			set.Set(65) // Set Bit 65 for tertiary
 		}
-		byteIdx := (f - 1) / 8
-		bitIdx := uint(7 - ((f - 1) % 8))
-		res[byteIdx] |= (1 << bitIdx)
 	}
-	return res, nil
+
+	// Determine size (8, 16 or 24 bytes)
+	size := 1
+	switch {
+	case set[2] != 0:
+		size = 3
+	case set[1] != 0:
+		size = 2
+	}
+	if size > 1 {
+		set.Set(1) // Set Bit 1 for secondary
+	}
+
+	for _, w := range set[:size] {
+		dst = binary.BigEndian.AppendUint64(dst, w)
+	}
+	return dst, nil
 }
 
 func (b *FBBitmap) Unpack(data []byte) (map[int]bool, int, error) {
+	set, readLen, err := b.UnpackBits(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return set.Map(), readLen, nil
+}
+
+// UnpackBits reads the bitmap as a set, bits 1 and 65 included
+func (b *FBBitmap) UnpackBits(data []byte) (Bits, int, error) {
 	if len(data) < 8 {
-		return nil, 0, fmt.Errorf("data too short for primary bitmap")
+		return Bits{}, 0, fmt.Errorf("data too short for primary bitmap")
 	}
 
-	fields := make(map[int]bool)
+	// Logic to check if we need to read 8, 16 or 24 bytes
 	readLen := 8
-
-	// Logic to check if we need to read 8 or 16 bytes
 	hasSecondary := (data[0] & 0x80) != 0
 	if hasSecondary {
 		readLen = 16
+		if len(data) < readLen {
+			return Bits{}, 0, fmt.Errorf("data too short for full bitmap")
+		}
+		if b.Tertiary && (data[8]&0x80) != 0 {
+			readLen = 24
+		}
 	}
 
 	if len(data) < readLen {
-		return nil, 0, fmt.Errorf("data too short for full bitmap")
+		return Bits{}, 0, fmt.Errorf("data too short for full bitmap")
 	}
 
-	for i := 0; i < readLen; i++ {
-		for bit := 0; bit < 8; bit++ {
-			if (data[i] & (1 << uint(7-bit))) != 0 {
-				fieldNum := i*8 + bit + 1
-				fields[fieldNum] = true
-			}
+	var fields Bits
+	for i := 0; i < readLen/8; i++ {
+		fields[i] = binary.BigEndian.Uint64(data[i*8:])
+	}
+	return fields, readLen, nil
+}
+
+func maxBitmapField(tertiary bool) int {
+	if tertiary {
+		return 192
+	}
+	return 128
+}
+
+// unpackHexBitmap reads a bitmap written as hex characters, 16 characters
+// per 64 fields; decode, if set, turns the wire bytes into ASCII hex (e.g., from EBCDIC)
+func unpackHexBitmap(data []byte, tertiary bool, name string, decode func([]byte) []byte) (Bits, int, error) {
+	var raw [24]byte
+	n, readLen := 0, 0
+
+	for {
+		if len(data) < readLen+16 {
+			return Bits{}, 0, fmt.Errorf("data too short for %s", name)
+		}
+		chunk := data[readLen : readLen+16]
+		if decode != nil {
+			chunk = decode(chunk)
+		}
+		if _, err := hex.Decode(raw[n:n+8], chunk); err != nil {
+			return Bits{}, 0, fmt.Errorf("invalid hex in %s: %v", name, err)
+		}
+		readLen += 16
+
+		// Bit 1 announces the secondary bitmap, bit 65 the tertiary one
+		more := raw[n]&0x80 != 0
+		n += 8
+		if n == 16 {
+			more = more && tertiary
+		}
+		if !more || n == 24 {
+			break
 		}
 	}
 
+	fields, _, err := (&FBBitmap{Tertiary: tertiary}).UnpackBits(raw[:n])
+	if err != nil {
+		return Bits{}, 0, err
+	}
 	return fields, readLen, nil
 }
+
+// appendHexBitmap appends the bitmap of fields as uppercase hex characters
+func appendHexBitmap(dst []byte, fields Bits, tertiary bool) ([]byte, error) {
+	var buf [24]byte
+	raw, err := (&FBBitmap{Tertiary: tertiary}).AppendBits(buf[:0], fields)
+	if err != nil {
+		return nil, err
+	}
+	const digits = "0123456789ABCDEF"
+	for _, c := range raw {
+		dst = append(dst, digits[c>>4], digits[c&0x0F])
+	}
+	return dst, nil
+}
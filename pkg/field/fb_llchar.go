@@ -6,7 +6,18 @@ import (
 
 type FBLLChar struct{}
 
+func init() {
+	Register("FBLLChar", func() ISOField {
+		return &FBLLChar{}
+	})
+}
+
 func (f *FBLLChar) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FBLLChar) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
@@ -15,7 +26,8 @@ func (f *FBLLChar) Pack(val string, length int) ([]byte, error) {
 	// 1-byte BCD header (e.g., length 12 -> 0x12)
 	header := byte(((dataLen / 10) << 4) | (dataLen % 10))
 
-	return append([]byte{header}, []byte(val)...), nil
+	dst = append(dst, header)
+	return append(dst, val...), nil
 }
 
 func (f *FBLLChar) Unpack(data []byte, length int) (string, int, error) {
@@ -32,3 +44,13 @@ func (f *FBLLChar) Unpack(data []byte, length int) (string, int, error) {
 
 	return string(data[1 : 1+dataLen]), 1 + dataLen, nil
 }
+
+// MaxLength is the largest length the BCD LL header can express
+func (f *FBLLChar) MaxLength() int {
+	return 99
+}
+
+// HeaderLen is the size in bytes of the BCD LL header
+func (f *FBLLChar) HeaderLen() int {
+	return 1
+}
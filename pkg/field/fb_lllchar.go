@@ -6,18 +6,29 @@ import (
 
 type FBLLLChar struct{}
 
+func init() {
+	Register("FBLLLChar", func() ISOField {
+		return &FBLLLChar{}
+	})
+}
+
 func (f *FBLLLChar) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FBLLLChar) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
 	}
 
 	// 2-byte BCD header for 3-4 digits (e.g., 125 -> 0x01, 0x25)
-	header := make([]byte, 2)
-	header[0] = byte(dataLen / 100)                         // Hundreds
-	header[1] = byte(((dataLen/10)%10)<<4 | (dataLen % 10)) // Tens and Units
-
-	return append(header, []byte(val)...), nil
+	dst = append(dst,
+		byte(dataLen/100),                       // Hundreds
+		byte(((dataLen/10)%10)<<4|(dataLen%10)), // Tens and Units
+	)
+	return append(dst, val...), nil
 }
 
 func (f *FBLLLChar) Unpack(data []byte, length int) (string, int, error) {
@@ -36,3 +47,13 @@ func (f *FBLLLChar) Unpack(data []byte, length int) (string, int, error) {
 
 	return string(data[2 : 2+dataLen]), 2 + dataLen, nil
 }
+
+// MaxLength is the largest length the BCD LLL header can express
+func (f *FBLLLChar) MaxLength() int {
+	return 999
+}
+
+// HeaderLen is the size in bytes of the BCD LLL header
+func (f *FBLLLChar) HeaderLen() int {
+	return 2
+}
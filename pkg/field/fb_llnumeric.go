@@ -7,23 +7,32 @@ import (
 
 type FBLLNumeric struct{}
 
+func init() {
+	Register("FBLLNumeric", func() ISOField {
+		return &FBLLNumeric{}
+	})
+}
+
 func (f *FBLLNumeric) Pack(val string, length int) ([]byte, error) {
+	return f.AppendPack(nil, val, length)
+}
+
+// AppendPack is Pack appending to dst
+func (f *FBLLNumeric) AppendPack(dst []byte, val string, length int) ([]byte, error) {
 	dataLen := len(val)
 	if dataLen > length {
 		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
 	}
+	// Character classes are FieldSpec.Class's job; only BCD nibbles are checked here
+	if err := checkBCD(val); err != nil {
+		return nil, fmt.Errorf("invalid BCD value for FB_LL_Numeric: %v", err)
+	}
 
 	// 1. Pack Length into 1 BCD byte (e.g., len 12 -> 0x12)
-	header := byte(((dataLen / 10) << 4) | (dataLen % 10))
+	dst = append(dst, byte(((dataLen/10)<<4)|(dataLen%10)))
 
-	// 2. Pack Data into BCD
-	padded := val
-	if len(padded)%2 != 0 {
-		padded = "0" + padded // Ensure even for BCD packing
-	}
-	dataBytes, _ := hex.DecodeString(padded)
-
-	return append([]byte{header}, dataBytes...), nil
+	// 2. Pack Data into BCD, left padded to an even number of digits
+	return appendBCD(dst, val, dataLen), nil
 }
 
 func (f *FBLLNumeric) Unpack(data []byte, length int) (string, int, error) {
@@ -50,3 +59,13 @@ func (f *FBLLNumeric) Unpack(data []byte, length int) (string, int, error) {
 
 	return res, 1 + byteLen, nil
 }
+
+// MaxLength is the largest length the BCD LL header can express
+func (f *FBLLNumeric) MaxLength() int {
+	return 99
+}
+
+// HeaderLen is the size in bytes of the BCD LL header
+func (f *FBLLNumeric) HeaderLen() int {
+	return 1
+}
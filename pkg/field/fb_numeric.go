@@ -3,21 +3,32 @@ package field
 import (
 	"encoding/hex"
 	"fmt"
-	"strings"
 )
 
 type FBNumeric struct{}
 
+func init() {
+	Register("FBNumeric", func() ISOField {
+		return &FBNumeric{}
+	})
+}
+
 func (f *FBNumeric) Pack(val string, length int) ([]byte, error) {
-	// 1. Pad to the required length
-	padded := strings.Repeat("0", length-len(val)) + val
+	return f.AppendPack(nil, val, length)
+}
 
-	// 2. BCD requires an even number of digits to form full bytes
-	if len(padded)%2 != 0 {
-		padded = "0" + padded
+// AppendPack is Pack appending to dst
+func (f *FBNumeric) AppendPack(dst []byte, val string, length int) ([]byte, error) {
+	// Character classes are FieldSpec.Class's job; only BCD nibbles are checked here
+	if err := checkBCD(val); err != nil {
+		return nil, fmt.Errorf("invalid BCD value for FB_Numeric: %v", err)
+	}
+	if len(val) > length {
+		return nil, fmt.Errorf("field length %d exceeds max %d", len(val), length)
 	}
 
-	return hex.DecodeString(padded)
+	// Pad to the required length, then to an even number of digits
+	return appendBCD(dst, val, length), nil
 }
 
 func (f *FBNumeric) Unpack(data []byte, length int) (string, int, error) {
@@ -0,0 +1,56 @@
+package field
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// FEBitmap carries the bitmap as hex characters in EBCDIC (16, 32 or 48 bytes)
+type FEBitmap struct {
+	CodePage *CodePage // nil means CP037
+	Tertiary bool
+}
+
+func init() {
+	RegisterBitmap("FEBitmap", func(tertiary bool) BitMap {
+		return &FEBitmap{Tertiary: tertiary}
+	})
+}
+
+// MaxField returns the highest field number this bitmap can carry
+func (f *FEBitmap) MaxField() int {
+	return maxBitmapField(f.Tertiary)
+}
+
+func (f *FEBitmap) Pack(fields map[int]bool) ([]byte, error) {
+	raw, err := (&FBBitmap{Tertiary: f.Tertiary}).Pack(fields)
+	if err != nil {
+		return nil, err
+	}
+	hexStr := strings.ToUpper(hex.EncodeToString(raw))
+	return codePageOrDefault(f.CodePage).Encode(hexStr), nil
+}
+
+func (f *FEBitmap) Unpack(data []byte) (map[int]bool, int, error) {
+	set, readLen, err := f.UnpackBits(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return set.Map(), readLen, nil
+}
+
+func (f *FEBitmap) AppendBits(dst []byte, fields Bits) ([]byte, error) {
+	start := len(dst)
+	dst, err := appendHexBitmap(dst, fields, f.Tertiary)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst[:start], codePageOrDefault(f.CodePage).Encode(string(dst[start:]))...), nil
+}
+
+func (f *FEBitmap) UnpackBits(data []byte) (Bits, int, error) {
+	cp := codePageOrDefault(f.CodePage)
+	return unpackHexBitmap(data, f.Tertiary, "FE_Bitmap", func(b []byte) []byte {
+		return []byte(cp.Decode(b))
+	})
+}
@@ -0,0 +1,35 @@
+package field
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FEChar is a fixed-length alphanumeric field in EBCDIC
+type FEChar struct {
+	CodePage *CodePage // nil means CP037
+}
+
+func init() {
+	Register("FEChar", func() ISOField {
+		return &FEChar{}
+	})
+}
+
+// Pack pads the string with spaces on the right and converts it to EBCDIC
+func (f *FEChar) Pack(val string, length int) ([]byte, error) {
+	if len(val) > length {
+		val = val[:length]
+	}
+
+	padded := val + strings.Repeat(" ", length-len(val))
+	return codePageOrDefault(f.CodePage).Encode(padded), nil
+}
+
+func (f *FEChar) Unpack(data []byte, length int) (string, int, error) {
+	if len(data) < length {
+		return "", 0, fmt.Errorf("insufficient data for FE_Char: need %d, got %d", length, len(data))
+	}
+
+	return codePageOrDefault(f.CodePage).Decode(data[:length]), length, nil
+}
@@ -0,0 +1,56 @@
+package field
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// FELLChar is a variable-length alphanumeric field with a 2-digit length, all in EBCDIC
+type FELLChar struct {
+	CodePage *CodePage // nil means CP037
+}
+
+func init() {
+	Register("FELLChar", func() ISOField {
+		return &FELLChar{}
+	})
+}
+
+func (f *FELLChar) Pack(val string, length int) ([]byte, error) {
+	dataLen := len(val)
+	if dataLen > length {
+		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
+	}
+
+	// 2-byte EBCDIC header (e.g., 5 -> "05" -> F0 F5)
+	header := fmt.Sprintf("%02d", dataLen)
+	return codePageOrDefault(f.CodePage).Encode(header + val), nil
+}
+
+func (f *FELLChar) Unpack(data []byte, length int) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, fmt.Errorf("insufficient data for EBCDIC LL header")
+	}
+
+	cp := codePageOrDefault(f.CodePage)
+	dataLen, err := strconv.Atoi(cp.Decode(data[:2]))
+	if err != nil || dataLen < 0 {
+		return "", 0, fmt.Errorf("invalid EBCDIC LL header: % X", data[:2])
+	}
+
+	if len(data) < 2+dataLen {
+		return "", 0, fmt.Errorf("insufficient data for FE_LL content: need %d, got %d", 2+dataLen, len(data))
+	}
+
+	return cp.Decode(data[2 : 2+dataLen]), 2 + dataLen, nil
+}
+
+// MaxLength is the largest length the EBCDIC LL header can express
+func (f *FELLChar) MaxLength() int {
+	return 99
+}
+
+// HeaderLen is the size in bytes of the EBCDIC LL header
+func (f *FELLChar) HeaderLen() int {
+	return 2
+}
@@ -0,0 +1,56 @@
+package field
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// FELLLChar is a variable-length alphanumeric field with a 3-digit length, all in EBCDIC
+type FELLLChar struct {
+	CodePage *CodePage // nil means CP037
+}
+
+func init() {
+	Register("FELLLChar", func() ISOField {
+		return &FELLLChar{}
+	})
+}
+
+func (f *FELLLChar) Pack(val string, length int) ([]byte, error) {
+	dataLen := len(val)
+	if dataLen > length {
+		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
+	}
+
+	// 3-byte EBCDIC header (e.g., 5 -> "005" -> F0 F0 F5)
+	header := fmt.Sprintf("%03d", dataLen)
+	return codePageOrDefault(f.CodePage).Encode(header + val), nil
+}
+
+func (f *FELLLChar) Unpack(data []byte, length int) (string, int, error) {
+	if len(data) < 3 {
+		return "", 0, fmt.Errorf("insufficient data for EBCDIC LLL header")
+	}
+
+	cp := codePageOrDefault(f.CodePage)
+	dataLen, err := strconv.Atoi(cp.Decode(data[:3]))
+	if err != nil || dataLen < 0 {
+		return "", 0, fmt.Errorf("invalid EBCDIC LLL header: % X", data[:3])
+	}
+
+	if len(data) < 3+dataLen {
+		return "", 0, fmt.Errorf("insufficient data for FE_LLL content: need %d, got %d", 3+dataLen, len(data))
+	}
+
+	return cp.Decode(data[3 : 3+dataLen]), 3 + dataLen, nil
+}
+
+// MaxLength is the largest length the EBCDIC LLL header can express
+func (f *FELLLChar) MaxLength() int {
+	return 999
+}
+
+// HeaderLen is the size in bytes of the EBCDIC LLL header
+func (f *FELLLChar) HeaderLen() int {
+	return 3
+}
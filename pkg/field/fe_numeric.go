@@ -0,0 +1,33 @@
+package field
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FENumeric is a fixed-length numeric field in EBCDIC (digits 0xF0-0xF9)
+type FENumeric struct {
+	CodePage *CodePage // nil means CP037
+}
+
+func init() {
+	Register("FENumeric", func() ISOField {
+		return &FENumeric{}
+	})
+}
+
+func (f *FENumeric) Pack(val string, length int) ([]byte, error) {
+	if len(val) > length {
+		val = val[:length]
+	}
+	// Pad left with '0'
+	padded := strings.Repeat("0", length-len(val)) + val
+	return codePageOrDefault(f.CodePage).Encode(padded), nil
+}
+
+func (f *FENumeric) Unpack(data []byte, length int) (string, int, error) {
+	if len(data) < length {
+		return "", 0, fmt.Errorf("insufficient data for FE_Numeric")
+	}
+	return codePageOrDefault(f.CodePage).Decode(data[:length]), length, nil
+}
@@ -8,4 +8,24 @@ type ISOField interface {
 type BitMap interface {
 	Pack(fields map[int]bool) ([]byte, error)
 	Unpack(data []byte) (map[int]bool, int, error)
+	// MaxField is the highest field number the bitmap can address (128 or 192)
+	MaxField() int
+}
+
+// LengthLimited is implemented by variable-length encoders; MaxLength is the
+// largest length their header can express
+type LengthLimited interface {
+	MaxLength() int
+}
+
+// Prefixed is implemented by encoders that write a length header before the
+// value; HeaderLen is its size in bytes
+type Prefixed interface {
+	HeaderLen() int
+}
+
+// Appender is implemented by encoders that can pack straight into a caller's
+// buffer, sparing an allocation per field; Pack is then AppendPack(nil, ...)
+type Appender interface {
+	AppendPack(dst []byte, val string, length int) ([]byte, error)
 }
@@ -0,0 +1,234 @@
+package field
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TLV is a single BER-TLV data object, e.g., EMV tag 9F26 (Application Cryptogram)
+type TLV struct {
+	Tag   string // Uppercase hex, e.g., "9F26"
+	Value []byte
+
+	// Raw is the object as received, with the padding around it, so that
+	// EncodeTLV can write it back unchanged; nil for objects built in code
+	Raw []byte
+}
+
+// ParseTLV splits BER-TLV encoded data into its data objects in wire order.
+// Multi-byte tags (first byte xxx11111) and long-form lengths (0x81-0x84) are
+// supported; 0x00/0xFF padding between objects is skipped as allowed by EMV
+// and kept in the Raw bytes of the next object (or the last one at the end).
+func ParseTLV(data []byte) ([]TLV, error) {
+	var tags []TLV
+	offset := 0
+	objStart := 0
+
+	for offset < len(data) {
+		if isPadding(data[offset]) {
+			offset++
+			continue
+		}
+
+		tag, value, end, err := readTLV(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, TLV{Tag: tag, Value: bytes.Clone(value), Raw: bytes.Clone(data[objStart:end])})
+		offset = end
+		objStart = end
+	}
+
+	if n := len(tags); n > 0 && objStart < len(data) {
+		tags[n-1].Raw = append(tags[n-1].Raw, data[objStart:]...)
+	}
+	return tags, nil
+}
+
+func isPadding(b byte) bool {
+	return b == 0x00 || b == 0xFF
+}
+
+// readTLV reads the data object starting at offset; value aliases data
+func readTLV(data []byte, offset int) (tag string, value []byte, end int, err error) {
+	// 1. Tag
+	start := offset
+	offset++
+	if data[start]&0x1F == 0x1F {
+		for {
+			if offset >= len(data) {
+				return "", nil, 0, fmt.Errorf("truncated TLV tag at offset %d", start)
+			}
+			b := data[offset]
+			offset++
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+	tag = strings.ToUpper(hex.EncodeToString(data[start:offset]))
+
+	// 2. Length
+	if offset >= len(data) {
+		return "", nil, 0, fmt.Errorf("missing length for TLV tag %s", tag)
+	}
+	length := int(data[offset])
+	offset++
+	if length&0x80 != 0 {
+		n := length & 0x7F
+		if n == 0 || n > 4 {
+			return "", nil, 0, fmt.Errorf("unsupported length form 0x%02X for TLV tag %s", length, tag)
+		}
+		if offset+n > len(data) {
+			return "", nil, 0, fmt.Errorf("truncated length for TLV tag %s", tag)
+		}
+		length = 0
+		for _, b := range data[offset : offset+n] {
+			length = length<<8 | int(b)
+		}
+		offset += n
+	}
+
+	// 3. Value
+	if length < 0 || offset+length > len(data) {
+		return "", nil, 0, fmt.Errorf("insufficient data for TLV tag %s: need %d, got %d", tag, length, len(data)-offset)
+	}
+	return tag, data[offset : offset+length], offset + length, nil
+}
+
+// EncodeTLV serialises data objects in the given order. Objects whose Raw
+// bytes still hold their tag and value are written as received, keeping
+// their length form; the others are encoded with minimal lengths. Padding
+// from Raw is kept either way.
+func EncodeTLV(tags []TLV) ([]byte, error) {
+	var res []byte
+	for _, t := range tags {
+		var lead, trail []byte
+		if t.Raw != nil {
+			start := 0
+			for start < len(t.Raw) && isPadding(t.Raw[start]) {
+				start++
+			}
+			if start < len(t.Raw) {
+				tag, value, end, err := readTLV(t.Raw, start)
+				if err == nil && strings.EqualFold(tag, t.Tag) && bytes.Equal(value, t.Value) {
+					res = append(res, t.Raw...)
+					continue
+				}
+				if err == nil {
+					trail = t.Raw[end:]
+				}
+			}
+			lead = t.Raw[:start]
+		}
+
+		tag, err := parseTag(t.Tag)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, lead...)
+		res = append(res, tag...)
+
+		l := len(t.Value)
+		switch {
+		case l < 0x80:
+			res = append(res, byte(l))
+		case l <= 0xFF:
+			res = append(res, 0x81, byte(l))
+		case l <= 0xFFFF:
+			res = append(res, 0x82, byte(l>>8), byte(l))
+		default:
+			return nil, fmt.Errorf("TLV tag %s value too long: %d bytes", t.Tag, l)
+		}
+		res = append(res, t.Value...)
+		res = append(res, trail...)
+	}
+	return res, nil
+}
+
+// parseTag validates a hex tag name against the BER tag structure
+func parseTag(tag string) ([]byte, error) {
+	b, err := hex.DecodeString(tag)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid TLV tag %q", tag)
+	}
+
+	multiByte := b[0]&0x1F == 0x1F
+	if !multiByte && len(b) != 1 {
+		return nil, fmt.Errorf("invalid TLV tag %q: single-byte tag form", tag)
+	}
+	if multiByte {
+		for i := 1; i < len(b); i++ {
+			last := i == len(b)-1
+			if (b[i]&0x80 == 0) != last {
+				return nil, fmt.Errorf("invalid TLV tag %q: bad continuation bits", tag)
+			}
+		}
+		if len(b) == 1 {
+			return nil, fmt.Errorf("invalid TLV tag %q: missing subsequent bytes", tag)
+		}
+	}
+	return b, nil
+}
+
+// FBLLLTLV carries BER-TLV data (e.g., field 55 EMV data) behind a 2-byte BCD
+// length counted in bytes. The field value is the uppercase hex of the TLV bytes.
+type FBLLLTLV struct{}
+
+func init() {
+	Register("FBLLLTLV", func() ISOField {
+		return &FBLLLTLV{}
+	})
+}
+
+func (f *FBLLLTLV) Pack(val string, length int) ([]byte, error) {
+	data, err := hex.DecodeString(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string for FB_LLL_TLV: %v", err)
+	}
+	if _, err := ParseTLV(data); err != nil {
+		return nil, err
+	}
+
+	dataLen := len(data)
+	if dataLen > length {
+		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
+	}
+
+	// 2-byte BCD header (e.g., 125 -> 0x01, 0x25)
+	header := make([]byte, 2)
+	header[0] = byte(dataLen / 100)
+	header[1] = byte(((dataLen/10)%10)<<4 | (dataLen % 10))
+
+	return append(header, data...), nil
+}
+
+// MaxLength is the largest length the BCD LLL header can express
+func (f *FBLLLTLV) MaxLength() int {
+	return 999
+}
+
+// HeaderLen is the size in bytes of the BCD LLL header
+func (f *FBLLLTLV) HeaderLen() int {
+	return 2
+}
+
+func (f *FBLLLTLV) Unpack(data []byte, length int) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, fmt.Errorf("insufficient data for BCD LLL header")
+	}
+
+	dataLen := int(data[0])*100 + int(data[1]>>4)*10 + int(data[1]&0x0F)
+	if len(data) < 2+dataLen {
+		return "", 0, fmt.Errorf("insufficient data for FB_LLL_TLV content: need %d, got %d", 2+dataLen, len(data))
+	}
+
+	raw := data[2 : 2+dataLen]
+	if _, err := ParseTLV(raw); err != nil {
+		return "", 0, err
+	}
+
+	return strings.ToUpper(hex.EncodeToString(raw)), 2 + dataLen, nil
+}
@@ -0,0 +1,106 @@
+package field
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Typical field 55 content: cryptogram, CID, TVR, transaction date, AIP,
+// plus a 0x81 long-form length on a proprietary tag
+const emvSample = "9F2608A1B2C3D4E5F60718" + "9F270180" + "95050000008000" + "9A03251018" + "82021980" +
+	"DF8101" + "8181" + "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F" +
+	"202122232425262728292A2B2C2D2E2F303132333435363738393A3B3C3D3E3F" +
+	"404142434445464748494A4B4C4D4E4F505152535455565758595A5B5C5D5E5F" +
+	"606162636465666768696A6B6C6D6E6F707172737475767778797A7B7C7D7E7F80"
+
+func TestParseTLV(t *testing.T) {
+	data, _ := hex.DecodeString(emvSample)
+	tags, err := ParseTLV(data)
+	if err != nil {
+		t.Fatalf("ParseTLV failed: %v", err)
+	}
+
+	wantTags := []string{"9F26", "9F27", "95", "9A", "82", "DF8101"}
+	if len(tags) != len(wantTags) {
+		t.Fatalf("got %d tags, want %d", len(tags), len(wantTags))
+	}
+	for i, tag := range wantTags {
+		if tags[i].Tag != tag {
+			t.Errorf("tag %d: got %s, want %s", i, tags[i].Tag, tag)
+		}
+	}
+	if hex.EncodeToString(tags[0].Value) != "a1b2c3d4e5f60718" {
+		t.Errorf("9F26: got % X", tags[0].Value)
+	}
+	if len(tags[5].Value) != 129 {
+		t.Errorf("DF8101: got %d bytes", len(tags[5].Value))
+	}
+
+	encoded, err := EncodeTLV(tags)
+	if err != nil {
+		t.Fatalf("EncodeTLV failed: %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("re-encoded TLV differs:\n got % X\nwant % X", encoded, data)
+	}
+}
+
+func TestEncodeTLVKeepsRaw(t *testing.T) {
+	// Padding and a non-minimal 0x81 length on 9F27, which is left alone
+	data, _ := hex.DecodeString("00" + "9F2608A1B2C3D4E5F60718" + "9F27810180" + "FFFF" + "95050000008000" + "00")
+	tags, err := ParseTLV(data)
+	if err != nil {
+		t.Fatalf("ParseTLV failed: %v", err)
+	}
+	if len(tags) != 3 {
+		t.Fatalf("got %d tags, want 3", len(tags))
+	}
+
+	tags[0].Value = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	encoded, err := EncodeTLV(tags)
+	if err != nil {
+		t.Fatalf("EncodeTLV failed: %v", err)
+	}
+	want := "009F26080102030405060708" + "9F27810180" + "FFFF" + "95050000008000" + "00"
+	if got := strings.ToUpper(hex.EncodeToString(encoded)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestParseTLVErrors(t *testing.T) {
+	for _, in := range []string{"9F", "9F26", "9F2608A1B2", "5F8181", "9F2685000000000001"} {
+		data, _ := hex.DecodeString(in)
+		if _, err := ParseTLV(data); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+
+	if _, err := EncodeTLV([]TLV{{Tag: "9F", Value: nil}}); err == nil {
+		t.Error("expected error for incomplete multi-byte tag")
+	}
+}
+
+func TestFBLLLTLV(t *testing.T) {
+	f := &FBLLLTLV{}
+	packed, err := f.Pack(emvSample, 255)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if packed[0] != 0x01 || packed[1] != 0x65 {
+		t.Errorf("length header: got % X", packed[:2])
+	}
+
+	val, readLen, err := f.Unpack(packed, 255)
+	if err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if val != emvSample || readLen != len(packed) {
+		t.Errorf("got %s (%d bytes)", val, readLen)
+	}
+
+	if _, err := f.Pack("9F2608A1", 255); err == nil {
+		t.Error("expected error for malformed TLV")
+	}
+}
@@ -0,0 +1,456 @@
+package field
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LengthPrefix encodes the length header of a variable-length field
+type LengthPrefix interface {
+	EncodeLength(length int) ([]byte, error)
+	// DecodeLength returns the announced length and the header size in bytes
+	DecodeLength(data []byte) (int, int, error)
+	// MaxLength is the largest length the header can express
+	MaxLength() int
+	// Size is the header size in bytes
+	Size() int
+}
+
+// Content encodes the value carried behind the length header
+type Content interface {
+	Encode(val string) ([]byte, error)
+	// Decode reads 'length' units and returns the value and the bytes consumed
+	Decode(data []byte, length int) (string, int, error)
+	// Units is the length of val as counted by the prefix (chars, digits or bytes)
+	Units(val string) (int, error)
+}
+
+// Variable is a variable-length field assembled from an independent length
+// prefix and content encoding, e.g., 4-digit ASCII LLLL + EBCDIC text, or a
+// 1-byte binary length + raw bytes. The length passed to Pack/Unpack is the
+// maximum allowed, in content units.
+type Variable struct {
+	Prefix  LengthPrefix
+	Content Content
+}
+
+func (v *Variable) Pack(val string, length int) ([]byte, error) {
+	dataLen, err := v.Content.Units(val)
+	if err != nil {
+		return nil, err
+	}
+	if dataLen > length {
+		return nil, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
+	}
+
+	header, err := v.Prefix.EncodeLength(dataLen)
+	if err != nil {
+		return nil, err
+	}
+	data, err := v.Content.Encode(val)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, data...), nil
+}
+
+func (v *Variable) Unpack(data []byte, length int) (string, int, error) {
+	dataLen, headerLen, err := v.Prefix.DecodeLength(data)
+	if err != nil {
+		return "", 0, err
+	}
+	if dataLen > length {
+		return "", 0, fmt.Errorf("field length %d exceeds max %d", dataLen, length)
+	}
+
+	val, readLen, err := v.Content.Decode(data[headerLen:], dataLen)
+	if err != nil {
+		return "", 0, err
+	}
+	return val, headerLen + readLen, nil
+}
+
+// MaxLength is the largest length the prefix can announce
+func (v *Variable) MaxLength() int {
+	return v.Prefix.MaxLength()
+}
+
+// HeaderLen is the size in bytes of the length prefix
+func (v *Variable) HeaderLen() int {
+	return v.Prefix.Size()
+}
+
+// ASCIIPrefix is a decimal length in ASCII digits (e.g., Digits 4 -> "0123")
+type ASCIIPrefix struct {
+	Digits int
+}
+
+func (p *ASCIIPrefix) EncodeLength(length int) ([]byte, error) {
+	if length > p.MaxLength() {
+		return nil, fmt.Errorf("length %d does not fit in %d ASCII digits", length, p.Digits)
+	}
+	return []byte(fmt.Sprintf("%0*d", p.Digits, length)), nil
+}
+
+func (p *ASCIIPrefix) DecodeLength(data []byte) (int, int, error) {
+	if len(data) < p.Digits {
+		return 0, 0, fmt.Errorf("insufficient data for ASCII length header")
+	}
+	length, err := decodeDigits(string(data[:p.Digits]), "ASCII")
+	return length, p.Digits, err
+}
+
+func (p *ASCIIPrefix) MaxLength() int {
+	return maxForDigits(p.Digits)
+}
+
+func (p *ASCIIPrefix) Size() int {
+	return p.Digits
+}
+
+// EBCDICPrefix is a decimal length in EBCDIC digits (e.g., Digits 2 -> F1 F2)
+type EBCDICPrefix struct {
+	Digits   int
+	CodePage *CodePage // nil means CP037
+}
+
+func (p *EBCDICPrefix) EncodeLength(length int) ([]byte, error) {
+	if length > p.MaxLength() {
+		return nil, fmt.Errorf("length %d does not fit in %d EBCDIC digits", length, p.Digits)
+	}
+	return codePageOrDefault(p.CodePage).Encode(fmt.Sprintf("%0*d", p.Digits, length)), nil
+}
+
+func (p *EBCDICPrefix) DecodeLength(data []byte) (int, int, error) {
+	if len(data) < p.Digits {
+		return 0, 0, fmt.Errorf("insufficient data for EBCDIC length header")
+	}
+	length, err := decodeDigits(codePageOrDefault(p.CodePage).Decode(data[:p.Digits]), "EBCDIC")
+	return length, p.Digits, err
+}
+
+func (p *EBCDICPrefix) MaxLength() int {
+	return maxForDigits(p.Digits)
+}
+
+func (p *EBCDICPrefix) Size() int {
+	return p.Digits
+}
+
+// BCDPrefix is a decimal length packed two digits per byte, right aligned
+// (e.g., Digits 4 -> 0x01 0x23 for 123)
+type BCDPrefix struct {
+	Digits int
+}
+
+func (p *BCDPrefix) byteLen() int {
+	return (p.Digits + 1) / 2
+}
+
+func (p *BCDPrefix) EncodeLength(length int) ([]byte, error) {
+	if length > p.MaxLength() {
+		return nil, fmt.Errorf("length %d does not fit in %d BCD digits", length, p.Digits)
+	}
+	return hex.DecodeString(fmt.Sprintf("%0*d", p.byteLen()*2, length))
+}
+
+func (p *BCDPrefix) DecodeLength(data []byte) (int, int, error) {
+	n := p.byteLen()
+	if len(data) < n {
+		return 0, 0, fmt.Errorf("insufficient data for BCD length header")
+	}
+	length, err := decodeDigits(hex.EncodeToString(data[:n]), "BCD")
+	return length, n, err
+}
+
+func (p *BCDPrefix) MaxLength() int {
+	return maxForDigits(p.Digits)
+}
+
+func (p *BCDPrefix) Size() int {
+	return p.byteLen()
+}
+
+// BinaryPrefix is an unsigned big-endian length of Bytes bytes (e.g., Visa's 1-byte length)
+type BinaryPrefix struct {
+	Bytes int
+}
+
+func (p *BinaryPrefix) EncodeLength(length int) ([]byte, error) {
+	if length > p.MaxLength() {
+		return nil, fmt.Errorf("length %d does not fit in %d-byte binary header", length, p.Bytes)
+	}
+	header := make([]byte, p.Bytes)
+	for i := p.Bytes - 1; i >= 0; i-- {
+		header[i] = byte(length)
+		length >>= 8
+	}
+	return header, nil
+}
+
+func (p *BinaryPrefix) DecodeLength(data []byte) (int, int, error) {
+	if len(data) < p.Bytes {
+		return 0, 0, fmt.Errorf("insufficient data for binary length header")
+	}
+	length := 0
+	for _, b := range data[:p.Bytes] {
+		length = length<<8 | int(b)
+	}
+	return length, p.Bytes, nil
+}
+
+func (p *BinaryPrefix) MaxLength() int {
+	return 1<<(8*p.Bytes) - 1
+}
+
+func (p *BinaryPrefix) Size() int {
+	return p.Bytes
+}
+
+// ASCIIContent carries the value as-is, one byte per character
+type ASCIIContent struct{}
+
+func (c *ASCIIContent) Encode(val string) ([]byte, error) {
+	return []byte(val), nil
+}
+
+func (c *ASCIIContent) Decode(data []byte, length int) (string, int, error) {
+	if len(data) < length {
+		return "", 0, fmt.Errorf("insufficient data for ASCII content: need %d, got %d", length, len(data))
+	}
+	return string(data[:length]), length, nil
+}
+
+func (c *ASCIIContent) Units(val string) (int, error) {
+	return len(val), nil
+}
+
+// EBCDICContent converts the value to and from EBCDIC
+type EBCDICContent struct {
+	CodePage *CodePage // nil means CP037
+}
+
+func (c *EBCDICContent) Encode(val string) ([]byte, error) {
+	return codePageOrDefault(c.CodePage).Encode(val), nil
+}
+
+func (c *EBCDICContent) Decode(data []byte, length int) (string, int, error) {
+	if len(data) < length {
+		return "", 0, fmt.Errorf("insufficient data for EBCDIC content: need %d, got %d", length, len(data))
+	}
+	return codePageOrDefault(c.CodePage).Decode(data[:length]), length, nil
+}
+
+func (c *EBCDICContent) Units(val string) (int, error) {
+	return len(val), nil
+}
+
+// BCDContent packs digits two per byte; odd lengths get a leading zero nibble
+type BCDContent struct{}
+
+func (c *BCDContent) Encode(val string) ([]byte, error) {
+	if err := checkBCD(val); err != nil {
+		return nil, fmt.Errorf("invalid digits for BCD content: %v", err)
+	}
+	return appendBCD(nil, val, 0), nil
+}
+
+func (c *BCDContent) Decode(data []byte, length int) (string, int, error) {
+	byteLen := (length + 1) / 2
+	if len(data) < byteLen {
+		return "", 0, fmt.Errorf("insufficient data for BCD content: need %d, got %d", byteLen, len(data))
+	}
+	res := hex.EncodeToString(data[:byteLen])
+	return res[len(res)-length:], byteLen, nil
+}
+
+func (c *BCDContent) Units(val string) (int, error) {
+	return len(val), nil
+}
+
+// BinaryContent carries raw bytes; the field value is their uppercase hex
+type BinaryContent struct{}
+
+func (c *BinaryContent) Encode(val string) ([]byte, error) {
+	b, err := hex.DecodeString(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string for binary content: %v", err)
+	}
+	return b, nil
+}
+
+func (c *BinaryContent) Decode(data []byte, length int) (string, int, error) {
+	if len(data) < length {
+		return "", 0, fmt.Errorf("insufficient data for binary content: need %d, got %d", length, len(data))
+	}
+	return strings.ToUpper(hex.EncodeToString(data[:length])), length, nil
+}
+
+func (c *BinaryContent) Units(val string) (int, error) {
+	if len(val)%2 != 0 {
+		return 0, fmt.Errorf("invalid hex string for binary content: odd length %d", len(val))
+	}
+	return len(val) / 2, nil
+}
+
+// TLVContent is BinaryContent that must hold well-formed BER-TLV data
+type TLVContent struct {
+	BinaryContent
+}
+
+func (c *TLVContent) Encode(val string) ([]byte, error) {
+	b, err := c.BinaryContent.Encode(val)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseTLV(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (c *TLVContent) Decode(data []byte, length int) (string, int, error) {
+	val, readLen, err := c.BinaryContent.Decode(data, length)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := ParseTLV(data[:length]); err != nil {
+		return "", 0, err
+	}
+	return val, readLen, nil
+}
+
+// NewLengthPrefix builds a prefix by type name: "ascii", "bcd" and "ebcdic"
+// take a number of digits, "binary" a number of bytes
+func NewLengthPrefix(kind string, size int) (LengthPrefix, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size %d for %s length prefix", size, kind)
+	}
+	switch kind {
+	case "ascii":
+		return &ASCIIPrefix{Digits: size}, nil
+	case "bcd":
+		return &BCDPrefix{Digits: size}, nil
+	case "ebcdic":
+		return &EBCDICPrefix{Digits: size}, nil
+	case "binary":
+		if size > 4 {
+			return nil, fmt.Errorf("binary length prefix supports at most 4 bytes, got %d", size)
+		}
+		return &BinaryPrefix{Bytes: size}, nil
+	}
+	return nil, fmt.Errorf("unknown length prefix type: %s", kind)
+}
+
+// NewContent builds a content encoding by name: "ascii", "ebcdic", "bcd", "binary" or "tlv"
+func NewContent(kind string) (Content, error) {
+	switch kind {
+	case "ascii":
+		return &ASCIIContent{}, nil
+	case "ebcdic":
+		return &EBCDICContent{}, nil
+	case "bcd":
+		return &BCDContent{}, nil
+	case "binary":
+		return &BinaryContent{}, nil
+	case "tlv":
+		return &TLVContent{}, nil
+	}
+	return nil, fmt.Errorf("unknown content encoding: %s", kind)
+}
+
+// Common combinations that have no dedicated struct
+func init() {
+	presets := map[string]func() ISOField{
+		"FALLLNumeric":  func() ISOField { return &Variable{Prefix: &ASCIIPrefix{Digits: 3}, Content: &ASCIIContent{}} },
+		"FALLLLChar":    func() ISOField { return &Variable{Prefix: &ASCIIPrefix{Digits: 4}, Content: &ASCIIContent{}} },
+		"FALLLLLLChar":  func() ISOField { return &Variable{Prefix: &ASCIIPrefix{Digits: 6}, Content: &ASCIIContent{}} },
+		"FBLLLLChar":    func() ISOField { return &Variable{Prefix: &BCDPrefix{Digits: 4}, Content: &ASCIIContent{}} },
+		"FBLLLNumeric":  func() ISOField { return &Variable{Prefix: &BCDPrefix{Digits: 3}, Content: &BCDContent{}} },
+		"FBLLHChar":     func() ISOField { return &Variable{Prefix: &BinaryPrefix{Bytes: 1}, Content: &ASCIIContent{}} },
+		"FBLLHBinary":   func() ISOField { return &Variable{Prefix: &BinaryPrefix{Bytes: 1}, Content: &BinaryContent{}} },
+		"FBLLLLHBinary": func() ISOField { return &Variable{Prefix: &BinaryPrefix{Bytes: 2}, Content: &BinaryContent{}} },
+		"FBLLHTLV":      func() ISOField { return &Variable{Prefix: &BinaryPrefix{Bytes: 1}, Content: &TLVContent{}} },
+		"FELLLLChar":    func() ISOField { return &Variable{Prefix: &EBCDICPrefix{Digits: 4}, Content: &EBCDICContent{}} },
+	}
+	for name, fn := range presets {
+		Register(name, fn)
+	}
+}
+
+func decodeDigits(s string, kind string) (int, error) {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid %s length header: %q", kind, s)
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+func maxForDigits(digits int) int {
+	res := 1
+	for i := 0; i < digits; i++ {
+		res *= 10
+	}
+	return res - 1
+}
+
+// appendDecimal appends n as decimal digits, zero-padded to width
+func appendDecimal(dst []byte, n int, width int) []byte {
+	var buf [20]byte
+	digits := strconv.AppendInt(buf[:0], int64(n), 10)
+	dst = appendRepeat(dst, '0', width-len(digits))
+	return append(dst, digits...)
+}
+
+// appendRepeat appends n copies of c; n may be negative
+func appendRepeat(dst []byte, c byte, n int) []byte {
+	for ; n > 0; n-- {
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// bcdNibble maps a character of a BCD value to its nibble: digits, and the
+// Track 2 field separator written 'D', 'd' or '='
+func bcdNibble(ch byte) (byte, bool) {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return ch - '0', true
+	case ch == 'D' || ch == 'd' || ch == '=':
+		return 0xD, true
+	}
+	return 0, false
+}
+
+// checkBCD reports the first character of val that has no BCD nibble
+func checkBCD(val string) error {
+	for i := 0; i < len(val); i++ {
+		if _, ok := bcdNibble(val[i]); !ok {
+			return fmt.Errorf("character %q at position %d is not a BCD digit", val[i], i)
+		}
+	}
+	return nil
+}
+
+// appendBCD packs the characters of val two per byte (see bcdNibble), left
+// padded with zeros to width digits and then to an even count
+func appendBCD(dst []byte, val string, width int) []byte {
+	pad := max(width-len(val), 0)
+	if (pad+len(val))%2 != 0 {
+		pad++
+	}
+	digit := func(i int) byte {
+		if i < pad {
+			return 0
+		}
+		n, _ := bcdNibble(val[i-pad])
+		return n
+	}
+	for i := 0; i < pad+len(val); i += 2 {
+		dst = append(dst, digit(i)<<4|digit(i+1))
+	}
+	return dst
+}
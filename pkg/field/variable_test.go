@@ -0,0 +1,71 @@
+package field
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVariableCombinations(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder ISOField
+		length  int
+		value   string
+		want    []byte
+	}{
+		{"ASCII LLLL", &Variable{&ASCIIPrefix{Digits: 4}, &ASCIIContent{}}, 9999, "HELLO", []byte("0005HELLO")},
+		{"ASCII LLLLLL", &Variable{&ASCIIPrefix{Digits: 6}, &ASCIIContent{}}, 999999, "AB", []byte("000002AB")},
+		{"BCD LLLL", &Variable{&BCDPrefix{Digits: 4}, &ASCIIContent{}}, 9999, "XYZ", []byte{0x00, 0x03, 'X', 'Y', 'Z'}},
+		{"BCD LL numeric odd", &Variable{&BCDPrefix{Digits: 2}, &BCDContent{}}, 19, "12345", []byte{0x05, 0x01, 0x23, 0x45}},
+		{"binary 1-byte", &Variable{&BinaryPrefix{Bytes: 1}, &BinaryContent{}}, 255, "9F2701AB", []byte{0x04, 0x9F, 0x27, 0x01, 0xAB}},
+		{"binary 2-byte", &Variable{&BinaryPrefix{Bytes: 2}, &ASCIIContent{}}, 65535, "OK", []byte{0x00, 0x02, 'O', 'K'}},
+		{"EBCDIC LL + EBCDIC", &Variable{&EBCDICPrefix{Digits: 2}, &EBCDICContent{}}, 99, "AB", []byte{0xF0, 0xF2, 0xC1, 0xC2}},
+		{"ASCII LL + EBCDIC", &Variable{&ASCIIPrefix{Digits: 2}, &EBCDICContent{}}, 99, "AB", []byte{'0', '2', 0xC1, 0xC2}},
+		{"binary TLV", &Variable{&BinaryPrefix{Bytes: 1}, &TLVContent{}}, 255, "9F270180", []byte{0x04, 0x9F, 0x27, 0x01, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packed, err := tt.encoder.Pack(tt.value, tt.length)
+			if err != nil {
+				t.Fatalf("Pack failed: %v", err)
+			}
+			if !bytes.Equal(packed, tt.want) {
+				t.Fatalf("Pack: got % X, want % X", packed, tt.want)
+			}
+
+			val, readLen, err := tt.encoder.Unpack(append(packed, 0x00), tt.length)
+			if err != nil {
+				t.Fatalf("Unpack failed: %v", err)
+			}
+			if val != tt.value || readLen != len(tt.want) {
+				t.Errorf("Unpack: got %q (%d bytes)", val, readLen)
+			}
+		})
+	}
+}
+
+func TestVariableErrors(t *testing.T) {
+	v := &Variable{&BinaryPrefix{Bytes: 1}, &ASCIIContent{}}
+	if _, err := v.Pack(string(make([]byte, 256)), 999); err == nil {
+		t.Error("expected error when length does not fit the prefix")
+	}
+	if _, err := v.Pack("ABCDE", 4); err == nil {
+		t.Error("expected error when value exceeds max length")
+	}
+	if _, _, err := v.Unpack([]byte{0x05, 'A'}, 99); err == nil {
+		t.Error("expected error for short content")
+	}
+	if _, _, err := (&Variable{&ASCIIPrefix{Digits: 2}, &ASCIIContent{}}).Unpack([]byte("-1A"), 99); err == nil {
+		t.Error("expected error for invalid length header")
+	}
+	if _, err := (&Variable{&BinaryPrefix{Bytes: 1}, &TLVContent{}}).Pack("9F27", 255); err == nil {
+		t.Error("expected error for malformed TLV")
+	}
+	if _, err := (&Variable{&BCDPrefix{Digits: 2}, &BCDContent{}}).Pack("12AB", 19); err == nil {
+		t.Error("expected error for hex letters in BCD content")
+	}
+	if _, err := NewLengthPrefix("binary", 8); err == nil {
+		t.Error("expected error for 8-byte binary prefix")
+	}
+}
@@ -0,0 +1,107 @@
+package iso8583
+
+import "testing"
+
+// benchSpecs are a typical ASCII and binary 1987 layout
+func benchSpecs(b *testing.B) map[string]*Spec {
+	ascii, err := LoadPreset(PresetISO87ASCII)
+	if err != nil {
+		b.Fatal(err)
+	}
+	binary, err := LoadSpecFromFile("../../iso87binary.yaml")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return map[string]*Spec{"ascii": ascii, "binary": binary}
+}
+
+// benchMessage is a purchase request as an acquirer would send it
+func benchMessage() *Message {
+	msg := NewMessage()
+	msg.MTI = "0200"
+	for id, val := range map[int]string{
+		2:  "4111111111111111",
+		3:  "000000",
+		4:  "000000010000",
+		11: "123456",
+		12: "103000",
+		13: "1018",
+		14: "2512",
+		22: "051",
+		25: "00",
+		37: "000000123456",
+		41: "TERM0001",
+		42: "MERCHANT0000001",
+		49: "840",
+		52: "0123456789ABCDEF",
+	} {
+		msg.Set(id, val)
+	}
+	return msg
+}
+
+func BenchmarkPack(b *testing.B) {
+	for name, spec := range benchSpecs(b) {
+		b.Run(name, func(b *testing.B) {
+			msg := benchMessage()
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := msg.Pack(spec); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnpack(b *testing.B) {
+	for name, spec := range benchSpecs(b) {
+		b.Run(name, func(b *testing.B) {
+			data, err := benchMessage().Pack(spec)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := NewMessage().Unpack(data, spec); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAppendPack(b *testing.B) {
+	for name, spec := range benchSpecs(b) {
+		b.Run(name, func(b *testing.B) {
+			msg := benchMessage()
+			var buf []byte
+			b.ReportAllocs()
+			for b.Loop() {
+				var err error
+				if buf, err = msg.AppendPack(buf[:0], spec); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnpackPooled(b *testing.B) {
+	for name, spec := range benchSpecs(b) {
+		b.Run(name, func(b *testing.B) {
+			data, err := benchMessage().Pack(spec)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				msg := AcquireMessage()
+				if err := msg.Unpack(data, spec); err != nil {
+					b.Fatal(err)
+				}
+				ReleaseMessage(msg)
+			}
+		})
+	}
+}
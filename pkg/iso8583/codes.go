@@ -0,0 +1,98 @@
+package iso8583
+
+import "fmt"
+
+// Field 39 holds a 2-character response code in ISO 8583:1987 and a 3-digit
+// action code in 1993 and 2003. The table maps the common response codes to
+// their action code equivalents.
+var responseToActionCode = map[string]string{
+	"00": "000", // Approved
+	"01": "107", // Refer to card issuer
+	"03": "109", // Invalid merchant
+	"04": "200", // Pick up card
+	"05": "100", // Do not honor
+	"12": "902", // Invalid transaction
+	"13": "110", // Invalid amount
+	"14": "111", // Invalid card number
+	"30": "904", // Format error
+	"41": "208", // Lost card, pick up
+	"43": "209", // Stolen card, pick up
+	"51": "116", // Insufficient funds
+	"54": "101", // Expired card
+	"55": "117", // Incorrect PIN
+	"57": "119", // Transaction not permitted to cardholder
+	"61": "121", // Exceeds withdrawal amount limit
+	"62": "104", // Restricted card
+	"65": "123", // Exceeds withdrawal frequency limit
+	"75": "106", // Allowable PIN tries exceeded
+	"91": "907", // Issuer or switch inoperative
+	"94": "913", // Duplicate transmission
+	"96": "909", // System malfunction
+}
+
+var actionToResponseCode = func() map[string]string {
+	m := make(map[string]string, len(responseToActionCode))
+	for rc, ac := range responseToActionCode {
+		m[ac] = rc
+	}
+	// Class-specific approvals
+	m["400"] = "00" // Reversal accepted
+	m["800"] = "00" // Network management accepted
+	return m
+}()
+
+// ActionCode converts a 1987 response code into the action code used from
+// 1993 on. Approvals of reversals and network management messages have their
+// own action codes (400 and 800), so the message class is needed.
+func ActionCode(responseCode string, class MTIClass) (string, bool) {
+	if responseCode == "00" {
+		switch class {
+		case ClassReversal:
+			return "400", true
+		case ClassNetworkManagement:
+			return "800", true
+		}
+	}
+	ac, ok := responseToActionCode[responseCode]
+	return ac, ok
+}
+
+// ResponseCode converts an action code back into a 1987 response code
+func ResponseCode(actionCode string) (string, bool) {
+	rc, ok := actionToResponseCode[actionCode]
+	return rc, ok
+}
+
+// SetResponseCode sets field 39 from a 1987 response code (e.g., "00"), as an
+// action code when the MTI is from a later version. Action codes are accepted
+// as is for those versions.
+func (m *Message) SetResponseCode(responseCode string) error {
+	mti, err := ParseMTI(m.MTI)
+	if err != nil {
+		return err
+	}
+	if mti.Version == Version1987 || len(responseCode) == 3 {
+		m.Set(39, responseCode)
+		return nil
+	}
+
+	ac, ok := ActionCode(responseCode, mti.Class)
+	if !ok {
+		return fmt.Errorf("no action code for response code %q", responseCode)
+	}
+	m.Set(39, ac)
+	return nil
+}
+
+// ResponseCode reads field 39 as a 1987 response code whatever the MTI
+// version; unknown action codes are returned unchanged
+func (m *Message) ResponseCode() string {
+	val := m.Get(39)
+	if len(val) != 3 {
+		return val
+	}
+	if rc, ok := ResponseCode(val); ok {
+		return rc
+	}
+	return val
+}
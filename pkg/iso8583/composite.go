@@ -0,0 +1,459 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// packBitmapped appends a bitmap followed by every present field in order.
+// It serves both the message body and sub-bitmapped composites such as field 127;
+// prefix is the parent path ("127.") so errors name the subfield, and start is
+// where the element begins in dst, for error offsets. A rawBitmap received
+// earlier is re-emitted as is when it still announces the same fields.
+func packBitmapped(dst []byte, start int, fields *Fields, spec *Spec, prefix string, rawBitmap []byte) ([]byte, error) {
+	maxField := spec.BitmapEncoder.MaxField()
+	var present field.Bits
+	for k := range fields.All() {
+		if k <= 1 {
+			continue
+		}
+		if k > maxField {
+			return nil, newPackError(prefix+"1", len(dst)-start, spec.BitmapEncoder,
+				fmt.Errorf("field %d exceeds bitmap capacity of %d fields", k, maxField))
+		}
+		present.Set(k)
+	}
+
+	if bitmapMatches(rawBitmap, present, spec.BitmapEncoder) {
+		dst = append(dst, rawBitmap...)
+	} else {
+		packed, err := appendBitmap(dst, present, spec.BitmapEncoder)
+		if err != nil {
+			return nil, newPackError(prefix+"1", len(dst)-start, spec.BitmapEncoder, err)
+		}
+		dst = packed
+	}
+
+	// Only the fields the bitmap can address get this far
+	for i := present.Next(1); i != 0; i = present.Next(i) {
+		if isBitmapIndicator(i, maxField) {
+			continue
+		}
+		path := prefix + strconv.Itoa(i)
+		fSpec, ok := spec.Fields.Lookup(i)
+		if !ok {
+			return nil, newPackError(path, len(dst)-start, nil, fmt.Errorf("present in message but not in spec"))
+		}
+		f := fields.Get(i)
+		offset := len(dst) - start
+		var err error
+		if dst, err = packField(dst, path, f, spec, fSpec); err != nil {
+			return nil, packErrorAt(err, offset)
+		}
+	}
+	return dst, nil
+}
+
+// appendBitmap appends the bitmap of present, through the map form of
+// BitMap for encoders that do not work on Bits
+func appendBitmap(dst []byte, present field.Bits, encoder field.BitMap) ([]byte, error) {
+	if a, ok := encoder.(field.BitmapAppender); ok {
+		return a.AppendBits(dst, present)
+	}
+	packed, err := encoder.Pack(present.Map())
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, packed...), nil
+}
+
+// readBitmap is appendBitmap for unpacking
+func readBitmap(data []byte, encoder field.BitMap) (field.Bits, int, error) {
+	if a, ok := encoder.(field.BitmapAppender); ok {
+		return a.UnpackBits(data)
+	}
+	var present field.Bits
+	fields, readLen, err := encoder.Unpack(data)
+	if err != nil {
+		return present, 0, err
+	}
+	for k, ok := range fields {
+		if ok && k >= 1 && k <= encoder.MaxField() {
+			present.Set(k)
+		}
+	}
+	return present, readLen, nil
+}
+
+// unpackBitmapped reads a bitmap and the fields it announces into fields.
+// base is the payload offset of data, used for error reporting, and a, if
+// set, provides the fields (data must then outlive them).
+// It returns the raw bitmap bytes and the total number of bytes consumed.
+func unpackBitmapped(data []byte, base int, prefix string, spec *Spec, fields *Fields, a *arena) ([]byte, int, error) {
+	present, offset, err := readBitmap(data, spec.BitmapEncoder)
+	if err != nil {
+		return nil, 0, newUnpackError(prefix+"1", base, spec.BitmapEncoder, err)
+	}
+	bitmap := data[:offset:offset]
+
+	// Walk the fields the bitmap announces, up to 128 or 192
+	maxField := spec.BitmapEncoder.MaxField()
+	for i := present.Next(1); i != 0; i = present.Next(i) {
+		if isBitmapIndicator(i, maxField) {
+			continue
+		}
+		path := prefix + strconv.Itoa(i)
+		fSpec, defined := spec.Fields.Lookup(i)
+		if !defined {
+			return nil, 0, newUnpackError(path, base+offset, nil, fmt.Errorf("found in bitmap but not in spec"))
+		}
+
+		f, readLen, err := unpackField(path, data[offset:], base+offset, spec, fSpec, a)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		fields.Set(i, f)
+		offset += readLen
+	}
+
+	return bitmap, offset, nil
+}
+
+// bitmapMatches reports whether raw decodes to exactly the present data fields
+func bitmapMatches(raw []byte, present field.Bits, encoder field.BitMap) bool {
+	if raw == nil {
+		return false
+	}
+	decoded, n, err := readBitmap(raw, encoder)
+	if err != nil || n != len(raw) {
+		return false
+	}
+
+	// Indicator bits follow from the data fields
+	decoded.Unset(1)
+	if isBitmapIndicator(65, encoder.MaxField()) {
+		decoded.Unset(65)
+	}
+	return decoded == present
+}
+
+// isBitmapIndicator reports whether a bit announces the next bitmap rather than
+// a data field: field 65 flags the tertiary bitmap once fields 129-192 are enabled
+func isBitmapIndicator(fieldNum int, maxField int) bool {
+	return fieldNum == 65 && maxField > 128
+}
+
+// packField appends a single field of spec, building composite content from its
+// subfields first. Untouched fields unpacked with the same spec are re-emitted from Raw.
+func packField(dst []byte, path string, f *Field, spec *Spec, fSpec FieldSpec) ([]byte, error) {
+	if f.spec == spec && !f.Dirty() {
+		return append(dst, f.Raw...), nil
+	}
+
+	val := f.text()
+	if fSpec.Subfields != nil && f.Subfields.Len() > 0 {
+		inner, err := packComposite(f.Subfields, fSpec.Subfields, path+".")
+		if err != nil {
+			return nil, err
+		}
+		val = string(inner)
+	}
+
+	if err := checkStrict(val, fSpec); err != nil {
+		return nil, newPackError(path, 0, fSpec.Encoder, err)
+	}
+	dst, err := appendPacked(dst, fSpec.Encoder, val, fSpec.Length)
+	if err != nil {
+		return nil, newPackError(path, 0, fSpec.Encoder, err)
+	}
+	return dst, nil
+}
+
+// appendPacked encodes val onto dst, in place for encoders implementing field.Appender
+func appendPacked(dst []byte, encoder field.ISOField, val string, length int) ([]byte, error) {
+	if a, ok := encoder.(field.Appender); ok {
+		return a.AppendPack(dst, val, length)
+	}
+	packed, err := encoder.Pack(val, length)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, packed...), nil
+}
+
+// packErrorAt points a PackError at the start of the element being written at
+// this level. Composites are built before their length prefix, so the offset
+// that survives is the one of the top-level field.
+func packErrorAt(err error, offset int) error {
+	var pe *PackError
+	if errors.As(err, &pe) {
+		pe.Offset = offset
+	}
+	return err
+}
+
+// checkStrict applies the strict-mode rules of a field to a value about to be
+// packed or just unpacked. Variable-length encoders enforce their own maximum;
+// fixed-length ones would otherwise truncate or pad silently.
+func checkStrict(val string, fSpec FieldSpec) error {
+	if !fSpec.Strict {
+		return nil
+	}
+	if err := fSpec.Class.Validate(val); err != nil {
+		return err
+	}
+	if _, variable := fSpec.Encoder.(field.LengthLimited); !variable && len(val) != fSpec.Length {
+		return fmt.Errorf("value length %d does not match fixed length %d", len(val), fSpec.Length)
+	}
+	return nil
+}
+
+// unpackField decodes a single field of spec starting at payload offset base and,
+// for composites, its subfields. With an arena, Raw refers into data.
+func unpackField(path string, data []byte, base int, spec *Spec, fSpec FieldSpec, a *arena) (*Field, int, error) {
+	val, readLen, err := fSpec.Encoder.Unpack(data, fSpec.Length)
+	if err != nil {
+		return nil, 0, newUnpackError(path, base, fSpec.Encoder, err)
+	}
+	if err := checkStrict(val, fSpec); err != nil {
+		return nil, 0, newUnpackError(path, base, fSpec.Encoder, err)
+	}
+
+	f := a.field()
+	f.Value = a.bytes(val)
+	f.Raw = data[:readLen:readLen]
+	if a == nil {
+		f.Raw = bytes.Clone(f.Raw)
+	}
+	f.str = val
+	f.unpacked = val
+	f.spec = spec
+
+	if fSpec.Subfields != nil {
+		// The content gets its own copy, for the subfields' Raw to refer into
+		f.Subfields = a.fieldTable()
+		if err := unpackComposite([]byte(val), contentOffset(base, readLen, val), path, fSpec.Subfields, f.Subfields, a); err != nil {
+			return nil, 0, err
+		}
+	}
+	return f, readLen, nil
+}
+
+// contentOffset is the payload offset of the value of a field read at base.
+// Subfield offsets are exact when the content travels byte for byte after the
+// length prefix (character encodings); otherwise they are relative to the
+// start of the field.
+func contentOffset(base, readLen int, val string) int {
+	if readLen >= len(val) {
+		return base + readLen - len(val)
+	}
+	return base
+}
+
+// packComposite encodes subfields according to the layout of the nested spec:
+// sub-bitmapped when it has a BitmapEncoder, tagged when TagLength is set,
+// otherwise fixed-position in subfield order.
+func packComposite(fields *Fields, spec *Spec, prefix string) ([]byte, error) {
+	var buf []byte
+
+	switch {
+	case spec.BitmapEncoder != nil:
+		var err error
+		if buf, err = packBitmapped(buf, 0, fields, spec, prefix, nil); err != nil {
+			return nil, err
+		}
+
+	case spec.TagLength > 0:
+		for id, f := range fields.All() {
+			path := prefix + strconv.Itoa(id)
+			fSpec, ok := spec.Fields.Lookup(id)
+			if !ok {
+				return nil, newPackError(path, len(buf), nil, fmt.Errorf("present in message but not in spec"))
+			}
+			offset := len(buf)
+			buf = fmt.Appendf(buf, "%0*d", spec.TagLength, id)
+			var err error
+			if buf, err = packField(buf, path, f, spec, fSpec); err != nil {
+				return nil, packErrorAt(err, offset)
+			}
+		}
+
+	default:
+		for id := range fields.All() {
+			if !spec.Fields.Has(id) {
+				return nil, newPackError(prefix+strconv.Itoa(id), 0, nil, fmt.Errorf("present in message but not in spec"))
+			}
+		}
+
+		// Positions up to the last present subfield are always written;
+		// missing ones in between are packed empty (i.e., padded)
+		last := fields.Last()
+		for id, fSpec := range spec.Fields.All() {
+			if id > last {
+				break
+			}
+			f, ok := fields.Lookup(id)
+			if !ok {
+				f = &Field{}
+			}
+			offset := len(buf)
+			var err error
+			if buf, err = packField(buf, prefix+strconv.Itoa(id), f, spec, fSpec); err != nil {
+				return nil, packErrorAt(err, offset)
+			}
+		}
+	}
+
+	return buf, nil
+}
+
+// unpackComposite is the inverse of packComposite; data must be consumed entirely.
+// path names the composite itself, base is the payload offset of data.
+func unpackComposite(data []byte, base int, path string, spec *Spec, fields *Fields, a *arena) error {
+	prefix := path + "."
+	offset := 0
+
+	switch {
+	case spec.BitmapEncoder != nil:
+		_, readLen, err := unpackBitmapped(data, base, prefix, spec, fields, a)
+		if err != nil {
+			return err
+		}
+		offset = readLen
+
+	case spec.TagLength > 0:
+		for offset < len(data) {
+			if len(data)-offset < spec.TagLength {
+				return newUnpackError(path, base+offset, nil, fmt.Errorf("insufficient data for subfield tag"))
+			}
+			id, err := strconv.Atoi(string(data[offset : offset+spec.TagLength]))
+			if err != nil {
+				return newUnpackError(path, base+offset, nil, fmt.Errorf("invalid subfield tag %q", data[offset:offset+spec.TagLength]))
+			}
+			subPath := prefix + strconv.Itoa(id)
+			fSpec, ok := spec.Fields.Lookup(id)
+			if !ok {
+				return newUnpackError(subPath, base+offset, nil, fmt.Errorf("found in data but not in spec"))
+			}
+			offset += spec.TagLength
+
+			f, readLen, err := unpackField(subPath, data[offset:], base+offset, spec, fSpec, a)
+			if err != nil {
+				return err
+			}
+			fields.Set(id, f)
+			offset += readLen
+		}
+
+	default:
+		// Trailing optional subfields may be left out by the sender
+		for id, fSpec := range spec.Fields.All() {
+			if offset >= len(data) {
+				break
+			}
+			f, readLen, err := unpackField(prefix+strconv.Itoa(id), data[offset:], base+offset, spec, fSpec, a)
+			if err != nil {
+				return err
+			}
+			fields.Set(id, f)
+			offset += readLen
+		}
+	}
+
+	if offset != len(data) {
+		return newUnpackError(path, base+offset, nil, fmt.Errorf("composite has %d unexpected trailing bytes", len(data)-offset))
+	}
+	return nil
+}
+
+// parsePath splits a dotted field path such as "127.22" into field numbers
+func parsePath(path string) ([]int, error) {
+	parts := strings.Split(path, ".")
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		id, err := strconv.Atoi(p)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// lookupPath walks a dotted path down the subfield tree
+func (m *Message) lookupPath(path string) (*Field, error) {
+	ids, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := &m.Fields
+	var f *Field
+	for _, id := range ids {
+		var ok bool
+		if f, ok = fields.Lookup(id); !ok {
+			return nil, nil
+		}
+		fields = f.Subfields
+	}
+	return f, nil
+}
+
+// GetPath retrieves a field or subfield by dotted path, e.g., "127.22" or "48.3"
+func (m *Message) GetPath(path string) string {
+	f, err := m.lookupPath(path)
+	if err != nil || f == nil {
+		return ""
+	}
+	return string(f.Value)
+}
+
+// SetPath sets a field or subfield by dotted path, creating composite parents as needed.
+// Composite values are rebuilt from their subfields when the message is packed.
+func (m *Message) SetPath(path string, value string) error {
+	ids, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	fields := &m.Fields
+	for _, id := range ids[:len(ids)-1] {
+		f, ok := fields.Lookup(id)
+		if !ok {
+			f = &Field{}
+			fields.Set(id, f)
+		}
+		if f.Subfields == nil {
+			f.Subfields = &Fields{}
+		}
+		f.MarkDirty()
+		fields = f.Subfields
+	}
+	fields.Set(ids[len(ids)-1], &Field{Value: []byte(value), str: value})
+	return nil
+}
+
+// UnsetPath removes a field or subfield by dotted path
+func (m *Message) UnsetPath(path string) error {
+	ids, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	fields := &m.Fields
+	for _, id := range ids[:len(ids)-1] {
+		f, ok := fields.Lookup(id)
+		if !ok || f.Subfields == nil {
+			return nil
+		}
+		f.MarkDirty()
+		fields = f.Subfields
+	}
+	fields.Delete(ids[len(ids)-1])
+	return nil
+}
@@ -0,0 +1,132 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"testing"
+)
+
+func TestCompositeRoundTrip(t *testing.T) {
+	spec := testSpec()
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(11, "000123")
+	for path, val := range map[string]string{
+		"48.1":   "SHOP",
+		"48.42":  "05",
+		"60.2":   "SN01",
+		"127.2":  "KEY",
+		"127.22": "<k>v</k>",
+	} {
+		if err := msg.SetPath(path, val); err != nil {
+			t.Fatalf("SetPath(%s) failed: %v", path, err)
+		}
+	}
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+
+	want := "0200" + "8020000000010010" + "0000000000000002" +
+		"000123" +
+		"013" + "0104SHOP" + "42005" +
+		"012" + "0000" + "SN01    " +
+		"032" + "4000040000000000" + "03KEY" + "008<k>v</k>"
+	if string(packed) != want {
+		t.Fatalf("packed mismatch:\n got %q\nwant %q", packed, want)
+	}
+
+	result := NewMessage()
+	if err := result.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+
+	for path, val := range map[string]string{
+		"48.1":   "SHOP",
+		"48.42":  "005",
+		"60.1":   "0000",
+		"60.2":   "SN01    ",
+		"60.3":   "",
+		"127.2":  "KEY",
+		"127.22": "<k>v</k>",
+	} {
+		if got := result.GetPath(path); got != val {
+			t.Errorf("%s: got %q, want %q", path, got, val)
+		}
+	}
+	if result.Get(48) != "0104SHOP42005" {
+		t.Errorf("field 48 raw content: got %q", result.Get(48))
+	}
+
+	// Changing one subfield rebuilds the composite on the next Pack
+	if err := result.UnsetPath("127.2"); err != nil {
+		t.Fatalf("UnsetPath failed: %v", err)
+	}
+	repacked, err := result.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	again := NewMessage()
+	if err := again.Unpack(repacked, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if again.GetPath("127.2") != "" || again.GetPath("127.22") != "<k>v</k>" {
+		t.Errorf("unexpected field 127 after unset: %q", again.Get(127))
+	}
+}
+
+func TestCompositeErrors(t *testing.T) {
+	spec := testSpec()
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	if err := msg.SetPath("127.9", "X"); err != nil {
+		t.Fatalf("SetPath failed: %v", err)
+	}
+	if _, err := msg.Pack(spec); err == nil {
+		t.Error("expected error for subfield missing from spec")
+	}
+
+	if err := msg.SetPath("127.x", "X"); err == nil {
+		t.Error("expected error for invalid path")
+	}
+
+	// Tag 07 is not defined for field 48
+	data := "0200" + "0000000000010000" + "008" + "07031AB"
+	if err := NewMessage().Unpack([]byte(data), spec); err == nil {
+		t.Error("expected error for unknown subfield tag")
+	}
+}
+
+func TestLoadSpecFromFileComposite(t *testing.T) {
+	spec, err := LoadSpecFromFile("../../spec.yaml")
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+
+	sub := spec.Fields.Get(127).Subfields
+	if sub == nil {
+		t.Fatal("field 127 is not a composite")
+	}
+	if _, ok := sub.BitmapEncoder.(*field.FABitmap); !ok {
+		t.Errorf("field 127 bitmap: got %T", sub.BitmapEncoder)
+	}
+	if _, ok := sub.Fields.Get(22).Encoder.(*field.FALLLChar); !ok {
+		t.Errorf("field 127.22: got %T", sub.Fields.Get(22).Encoder)
+	}
+
+	path := writeSpec(t, `
+fields:
+  127:
+    length: 999
+    encoder: "FALLLChar"
+    subfields:
+      22:
+        length: 999
+        encoder: "FXChar"
+`)
+	if _, err := LoadSpecFromFile(path); err == nil || err.Error() != "field 127.22: unknown field encoder: FXChar" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
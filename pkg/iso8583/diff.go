@@ -0,0 +1,73 @@
+package iso8583
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ChangeKind classifies a difference between two messages
+type ChangeKind string
+
+const (
+	FieldAdded   ChangeKind = "added"
+	FieldRemoved ChangeKind = "removed"
+	FieldChanged ChangeKind = "changed"
+)
+
+// Change is one difference reported by Diff. Path is a dotted field path
+// ("0" for the MTI, "127.22" for a subfield).
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("%s added: %q", describePath(c.Path), c.New)
+	case FieldRemoved:
+		return fmt.Sprintf("%s removed: %q", describePath(c.Path), c.Old)
+	}
+	return fmt.Sprintf("%s changed: %q -> %q", describePath(c.Path), c.Old, c.New)
+}
+
+// Diff reports how b differs from a, in field order. Composites present on
+// both sides with subfields are compared subfield by subfield.
+func Diff(a, b *Message) []Change {
+	var changes []Change
+	if a.MTI != b.MTI {
+		changes = append(changes, Change{Path: strconv.Itoa(FieldMTI), Kind: FieldChanged, Old: a.MTI, New: b.MTI})
+	}
+	return diffFields(&a.Fields, &b.Fields, "", changes)
+}
+
+func diffFields(a, b *Fields, prefix string, changes []Change) []Change {
+	ids := a.Keys()
+	for id := range b.All() {
+		if !a.Has(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		path := prefix + strconv.Itoa(id)
+		fa, inA := a.Lookup(id)
+		fb, inB := b.Lookup(id)
+
+		switch {
+		case !inB:
+			changes = append(changes, Change{Path: path, Kind: FieldRemoved, Old: string(fa.Value)})
+		case !inA:
+			changes = append(changes, Change{Path: path, Kind: FieldAdded, New: string(fb.Value)})
+		case fa.Subfields.Len() > 0 && fb.Subfields.Len() > 0:
+			changes = diffFields(fa.Subfields, fb.Subfields, path+".", changes)
+		case string(fa.Value) != string(fb.Value):
+			changes = append(changes, Change{Path: path, Kind: FieldChanged, Old: string(fa.Value), New: string(fb.Value)})
+		}
+	}
+	return changes
+}
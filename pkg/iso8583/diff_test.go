@@ -0,0 +1,76 @@
+package iso8583
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCloneIsDeep(t *testing.T) {
+	spec := testSpec()
+	src := NewMessage()
+	src.MTI = "0200"
+	src.Set(11, "000123")
+	src.SetPath("127.2", "KEY")
+	packed, _ := src.Pack(spec)
+
+	orig := NewMessage()
+	if err := orig.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	orig.SetHeader([]byte{0x60, 0x00, 0x01})
+
+	c := orig.Clone()
+	if changes := Diff(orig, c); len(changes) != 0 {
+		t.Fatalf("clone differs: %v", changes)
+	}
+
+	c.Fields.Get(11).Value[0] = '9'
+	c.Fields.Get(127).Subfields.Get(2).Value[0] = 'X'
+	c.Header[0] = 0xFF
+	c.Bitmap[0] = 'F'
+	c.Set(39, "00")
+
+	if orig.Get(11) != "000123" || orig.GetPath("127.2") != "KEY" || orig.Header[0] != 0x60 || orig.Bitmap[0] == 'F' {
+		t.Error("modifying the clone changed the original")
+	}
+	if orig.Fields.Has(39) {
+		t.Error("field added to the clone shows up in the original")
+	}
+
+	// Untouched raw bytes travel with the clone
+	repacked, err := orig.Clone().Pack(spec)
+	if err != nil || string(repacked) != string(packed) {
+		t.Errorf("clone repack: %v\n got %s\nwant %s", err, repacked, packed)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := NewMessage()
+	a.MTI = "0200"
+	a.Set(3, "000000")
+	a.Set(4, "1000")
+	a.Set(41, "TERM01")
+	a.SetPath("127.2", "KEY")
+
+	b := a.Clone()
+	b.MTI = "0210"
+	b.Set(4, "2000")
+	b.Unset(41)
+	b.Set(39, "00")
+	b.SetPath("127.22", "DATA")
+
+	want := []Change{
+		{Path: "0", Kind: FieldChanged, Old: "0200", New: "0210"},
+		{Path: "4", Kind: FieldChanged, Old: "1000", New: "2000"},
+		{Path: "39", Kind: FieldAdded, New: "00"},
+		{Path: "41", Kind: FieldRemoved, Old: "TERM01"},
+		{Path: "127.22", Kind: FieldAdded, New: "DATA"},
+	}
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if got[0].String() != `MTI changed: "0200" -> "0210"` || got[3].String() != `field 41 removed: "TERM01"` {
+		t.Errorf("unexpected strings: %s, %s", got[0], got[3])
+	}
+}
@@ -0,0 +1,193 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Dump decodes a packed message element by element and lists, one line each,
+// its payload offset, field path, encoder, length prefix, raw bytes, decoded
+// value and description. data is Pack output or a frame captured off the wire
+// without its channel length header. Decoding stops at the first error, which
+// is listed in place of the element that broke, so the bytes up to a rejected
+// field can still be read. Values and raw bytes of sensitive fields are
+// masked as in LogString unless the spec sets LogClear.
+//
+//	OFFSET  FIELD  ENCODER      PREFIX  RAW                  VALUE               DESCRIPTION
+//	0000    MTI    FANumeric            30 32 30 30          "0200"              Message Type Indicator
+//	0004    1      FBBitmap             72 30 00 ...         [2 3 4 7 11 ...]    Bitmap
+//	0012    2      FALLNumeric  31 36   34 31 31 31 ...      "4111111111111111"  Primary Account Number
+func Dump(data []byte, spec *Spec) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tFIELD\tENCODER\tPREFIX\tRAW\tVALUE\tDESCRIPTION")
+
+	d := &dumper{w: w, clear: spec.LogClear}
+	if end, ok := d.message(data, spec); ok && end < len(data) {
+		d.line(end, "", "", nil, d.raw(data[end:], !d.clear), "", fmt.Sprintf("%d trailing bytes", len(data)-end))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// dumper mirrors the unpack path, writing a line per element it decodes
+type dumper struct {
+	w     io.Writer
+	spec  *Spec // Spec of the message version, for masks
+	clear bool
+}
+
+func (d *dumper) line(offset int, path, encoder string, prefix []byte, raw, value, desc string) {
+	fmt.Fprintf(d.w, "%04d\t%s\t%s\t% X\t%s\t%s\t%s\n", offset, path, encoder, prefix, raw, value, desc)
+}
+
+// raw formats bytes as hex, or just their count when hidden
+func (d *dumper) raw(data []byte, hide bool) string {
+	if hide {
+		return fmt.Sprintf("<%d bytes>", len(data))
+	}
+	return fmt.Sprintf("% X", data)
+}
+
+func (d *dumper) mask(path string) Mask {
+	if d.clear {
+		return MaskNone
+	}
+	return d.spec.MaskFor(path)
+}
+
+// fail lists an error at the offset of the element that broke
+func (d *dumper) fail(offset int, err error) {
+	var ue *UnpackError
+	if errors.As(err, &ue) {
+		d.line(ue.Offset, ue.Path, ue.Encoder, nil, "", "ERROR: "+ue.Err.Error(), "")
+		return
+	}
+	d.line(offset, "", "", nil, "", "ERROR: "+err.Error(), "")
+}
+
+// message dumps the MTI, bitmap and fields, returning the bytes consumed
+func (d *dumper) message(data []byte, spec *Spec) (int, bool) {
+	mti, n, err := spec.MTIEncoder.Unpack(data, 4)
+	if err != nil {
+		d.fail(0, newUnpackError("0", 0, spec.MTIEncoder, err))
+		return 0, false
+	}
+	d.line(0, "MTI", encoderName(spec.MTIEncoder), nil, d.raw(data[:n], false), strconv.Quote(mti), "Message Type Indicator")
+
+	if spec, err = spec.forMTI(mti); err != nil {
+		d.fail(0, err)
+		return n, false
+	}
+	d.spec = spec
+	read, ok := d.bitmapped(data[n:], n, "", spec)
+	return n + read, ok
+}
+
+func (d *dumper) bitmapped(data []byte, base int, prefix string, spec *Spec) (int, bool) {
+	present, offset, err := spec.BitmapEncoder.Unpack(data)
+	if err != nil {
+		d.fail(base, newUnpackError(prefix+"1", base, spec.BitmapEncoder, err))
+		return 0, false
+	}
+
+	maxField := spec.BitmapEncoder.MaxField()
+	var ids []int
+	for i := 2; i <= maxField; i++ {
+		if present[i] && !isBitmapIndicator(i, maxField) {
+			ids = append(ids, i)
+		}
+	}
+	d.line(base, prefix+"1", encoderName(spec.BitmapEncoder), nil, d.raw(data[:offset], false), fmt.Sprint(ids), "Bitmap")
+
+	for _, id := range ids {
+		path := prefix + strconv.Itoa(id)
+		fSpec, ok := spec.Fields.Lookup(id)
+		if !ok {
+			d.fail(base+offset, newUnpackError(path, base+offset, nil, fmt.Errorf("found in bitmap but not in spec")))
+			return offset, false
+		}
+		n, ok := d.field(path, nil, data[offset:], base+offset, spec, fSpec)
+		if !ok {
+			return offset, false
+		}
+		offset += n
+	}
+	return offset, true
+}
+
+// field dumps one field and, for composites, its subfields. tag is the
+// subfield tag of tagged composites, shown with the length prefix.
+func (d *dumper) field(path string, tag, data []byte, base int, spec *Spec, fSpec FieldSpec) (int, bool) {
+	f, readLen, err := unpackField(path, data, base, spec, fSpec, nil)
+	if err != nil {
+		d.fail(base, err)
+		return 0, false
+	}
+
+	raw := f.Raw
+	prefix := tag
+	if p, ok := fSpec.Encoder.(field.Prefixed); ok && p.HeaderLen() <= len(raw) {
+		prefix = append(bytes.Clone(tag), raw[:p.HeaderLen()]...)
+		raw = raw[p.HeaderLen():]
+	}
+	// Composites hiding a subfield hide their content, listed below anyway
+	value := strconv.Quote(string(f.Value))
+	mask := d.mask(path)
+	hide := mask.masked() || (!d.clear && fSpec.Subfields != nil && fSpec.Subfields.masksBelow())
+	switch {
+	case mask.masked():
+		value = strconv.Quote(mask.Apply(string(f.Value)))
+	case hide:
+		value = ""
+	}
+	d.line(base-len(tag), path, encoderName(fSpec.Encoder), prefix, d.raw(raw, hide), value, fSpec.Description)
+
+	if fSpec.Subfields != nil {
+		d.composite(f.Value, contentOffset(base, readLen, string(f.Value)), path, fSpec.Subfields)
+	}
+	return readLen, true
+}
+
+// composite dumps the subfields of a composite that unpacked successfully,
+// following the same layout rules as unpackComposite
+func (d *dumper) composite(data []byte, base int, path string, spec *Spec) {
+	prefix := path + "."
+
+	switch {
+	case spec.BitmapEncoder != nil:
+		d.bitmapped(data, base, prefix, spec)
+
+	case spec.TagLength > 0:
+		for offset := 0; offset+spec.TagLength <= len(data); {
+			tag := data[offset : offset+spec.TagLength]
+			id, _ := strconv.Atoi(string(tag))
+			offset += spec.TagLength
+			fSpec := spec.Fields.Get(id)
+			n, ok := d.field(prefix+strconv.Itoa(id), tag, data[offset:], base+offset, spec, fSpec)
+			if !ok {
+				return
+			}
+			offset += n
+		}
+
+	default:
+		offset := 0
+		for id, fSpec := range spec.Fields.All() {
+			if offset >= len(data) {
+				break
+			}
+			n, ok := d.field(prefix+strconv.Itoa(id), nil, data[offset:], base+offset, spec, fSpec)
+			if !ok {
+				return
+			}
+			offset += n
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"strings"
+	"testing"
+)
+
+func dumpSpec() *Spec {
+	return withFields(testSpec(), map[int]FieldSpec{
+		2: {Length: 19, Encoder: &field.FALLNumeric{}, Description: "Primary Account Number"},
+		3: {Length: 6, Encoder: &field.FANumeric{}, Description: "Processing Code"},
+	})
+}
+
+func TestDump(t *testing.T) {
+	spec := dumpSpec()
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(2, "4111111111111111")
+	msg.Set(3, "000000")
+	msg.SetPath("48.1", "ABC")
+
+	data, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(Dump(data, spec)), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected header and 6 lines, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	for i, want := range [][]string{
+		{"0000", "MTI", "FANumeric", "30 32 30 30", `"0200"`},
+		{"0004", "1", "FABitmap", "[2 3 48]"},
+		{"0020", "2", "FALLNumeric", "31 36", "<16 bytes>", `"411111******1111"`, "Primary Account Number"},
+		{"0038", "3", "FANumeric", `"000000"`, "Processing Code"},
+		{"0044", "48", "FALLLChar", "30 30 37", `"0103ABC"`, "Additional Data"},
+		{"0047", "48.1", "FALLChar", "30 31 30 33", "41 42 43", `"ABC"`, "Merchant Name"},
+	} {
+		for _, s := range want {
+			if !strings.Contains(lines[i+1], s) {
+				t.Errorf("line %d %q: missing %q", i+1, lines[i+1], s)
+			}
+		}
+	}
+}
+
+func TestDumpStopsAtError(t *testing.T) {
+	spec := dumpSpec()
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(2, "4111111111111111")
+	msg.Set(3, "000000")
+	data, _ := msg.Pack(spec)
+
+	// Corrupt the LL header of field 2
+	data[20] = 'X'
+	out := Dump(append(data, 0xFF), spec)
+	if !strings.Contains(out, "Bitmap") || !strings.Contains(out, "ERROR: invalid LL header") {
+		t.Errorf("unexpected dump:\n%s", out)
+	}
+	if strings.Contains(out, "Processing Code") || strings.Contains(out, "trailing") {
+		t.Errorf("dump went on after the error:\n%s", out)
+	}
+}
@@ -0,0 +1,238 @@
+package iso8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the JSON/YAML form of a message:
+//
+//	{"header": "6000010000", "mti": "0200", "fields": {"3": "000000", "127": {"2": "KEY"}}}
+//
+// The header is hex, composites with subfields become nested objects, and
+// field values are strings exactly as they decode from the wire.
+type document struct {
+	Header string    `json:"header,omitempty" yaml:"header,omitempty"`
+	MTI    string    `json:"mti" yaml:"mti"`
+	Fields fieldsDoc `json:"fields" yaml:"fields"`
+}
+
+// fieldsDoc keeps fields in numeric order, which encoding/json would not
+type fieldsDoc map[int]fieldDoc
+
+// fieldDoc is either a plain value or, for composites, nested subfields
+type fieldDoc struct {
+	Value     string
+	Subfields fieldsDoc
+}
+
+// EncodeJSON renders a message as indented JSON. With a spec, every value is
+// normalized through its encoder, so numerics keep their leading zeros and
+// binary fields appear as uppercase hex; spec may be nil to keep values as set.
+func EncodeJSON(m *Message, spec *Spec) ([]byte, error) {
+	doc, err := toDocument(m, spec)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// DecodeJSON is the inverse of EncodeJSON; with a spec, every field must be defined in it
+func DecodeJSON(data []byte, spec *Spec) (*Message, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return fromDocument(doc, spec)
+}
+
+// EncodeYAML is EncodeJSON for YAML
+func EncodeYAML(m *Message, spec *Spec) ([]byte, error) {
+	doc, err := toDocument(m, spec)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// DecodeYAML is DecodeJSON for YAML
+func DecodeYAML(data []byte, spec *Spec) (*Message, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return fromDocument(doc, spec)
+}
+
+func toDocument(m *Message, spec *Spec) (document, error) {
+	doc := document{
+		Header: strings.ToUpper(hex.EncodeToString(m.Header)),
+		MTI:    m.MTI,
+	}
+	if spec != nil {
+		var err error
+		if spec, err = spec.forMTI(m.MTI); err != nil {
+			return doc, err
+		}
+	}
+
+	var err error
+	doc.Fields, err = toFieldsDoc(&m.Fields, spec, "")
+	return doc, err
+}
+
+func toFieldsDoc(fields *Fields, spec *Spec, prefix string) (fieldsDoc, error) {
+	res := make(fieldsDoc, fields.Len())
+	for id, f := range fields.All() {
+		path := prefix + strconv.Itoa(id)
+
+		var fSpec FieldSpec
+		if spec != nil {
+			var ok bool
+			if fSpec, ok = spec.Fields.Lookup(id); !ok {
+				return nil, fmt.Errorf("field %s is not defined in spec", path)
+			}
+		}
+
+		if f.Subfields.Len() > 0 {
+			sub, err := toFieldsDoc(f.Subfields, fSpec.Subfields, path+".")
+			if err != nil {
+				return nil, err
+			}
+			res[id] = fieldDoc{Subfields: sub}
+			continue
+		}
+
+		val := string(f.Value)
+		if spec != nil {
+			var err error
+			if val, err = normalizeValue(val, fSpec); err != nil {
+				return nil, fmt.Errorf("field %s: %w", path, err)
+			}
+		}
+		res[id] = fieldDoc{Value: val}
+	}
+	return res, nil
+}
+
+// normalizeValue runs a value through its encoder, yielding the string the
+// receiving side would decode (padding, leading zeros, hex case)
+func normalizeValue(val string, fSpec FieldSpec) (string, error) {
+	packed, err := fSpec.Encoder.Pack(val, fSpec.Length)
+	if err != nil {
+		return "", err
+	}
+	res, _, err := fSpec.Encoder.Unpack(packed, fSpec.Length)
+	return res, err
+}
+
+func fromDocument(doc document, spec *Spec) (*Message, error) {
+	header, err := hex.DecodeString(doc.Header)
+	if err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+
+	m := NewMessage()
+	m.MTI = doc.MTI
+	if len(header) > 0 {
+		m.Header = header
+	}
+	if spec != nil {
+		if spec, err = spec.forMTI(m.MTI); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := fromFieldsDoc(&m.Fields, doc.Fields, spec, ""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fromFieldsDoc is the inverse of toFieldsDoc, filling dst
+func fromFieldsDoc(dst *Fields, doc fieldsDoc, spec *Spec, prefix string) error {
+	for id, fd := range doc {
+		path := prefix + strconv.Itoa(id)
+
+		var fSpec FieldSpec
+		if spec != nil {
+			var ok bool
+			if fSpec, ok = spec.Fields.Lookup(id); !ok {
+				return fmt.Errorf("field %s is not defined in spec", path)
+			}
+		}
+
+		if fd.Subfields != nil {
+			if spec != nil && fSpec.Subfields == nil {
+				return fmt.Errorf("field %s is not a composite in spec", path)
+			}
+			f := &Field{Subfields: &Fields{}}
+			if err := fromFieldsDoc(f.Subfields, fd.Subfields, fSpec.Subfields, path+"."); err != nil {
+				return err
+			}
+			dst.Set(id, f)
+			continue
+		}
+		dst.Set(id, &Field{Value: []byte(fd.Value)})
+	}
+	return nil
+}
+
+func (d fieldsDoc) MarshalJSON() ([]byte, error) {
+	keys := make([]int, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		val, err := json.Marshal(d[k])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%q:", strconv.Itoa(k))
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (f fieldDoc) MarshalJSON() ([]byte, error) {
+	if f.Subfields != nil {
+		return json.Marshal(f.Subfields)
+	}
+	return json.Marshal(f.Value)
+}
+
+func (f *fieldDoc) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		return json.Unmarshal(data, &f.Subfields)
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+func (f fieldDoc) MarshalYAML() (any, error) {
+	if f.Subfields != nil {
+		return map[int]fieldDoc(f.Subfields), nil
+	}
+	return f.Value, nil
+}
+
+func (f *fieldDoc) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		return node.Decode(&f.Subfields)
+	}
+	return node.Decode(&f.Value)
+}
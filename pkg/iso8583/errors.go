@@ -0,0 +1,118 @@
+package iso8583
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field numbers reported for errors outside the data elements, following the
+// ISO convention that bit 1 belongs to the bitmap. In a Path, "127.1" is the
+// sub-bitmap of field 127.
+const (
+	FieldMTI    = 0
+	FieldBitmap = 1
+)
+
+// rawContext is how many bytes before and after the failing offset end up in UnpackError.Raw
+const rawContext = 16
+
+// UnpackError reports where Message.Unpack gave up on a payload
+type UnpackError struct {
+	Field   int    // Top-level field number, or FieldMTI/FieldBitmap
+	Path    string // Dotted path down to the failing subfield, e.g., "127.22"
+	Offset  int    // Byte offset into the payload where the failing element starts
+	Encoder string // Encoder type, e.g., "FALLChar"; empty for spec mismatches
+	Raw     []byte // Payload bytes around Offset
+	RawFrom int    // Offset of Raw[0] in the payload
+	Err     error
+}
+
+func (e *UnpackError) Error() string {
+	return fmt.Sprintf("unpack %s%s at offset %d: %v", describePath(e.Path), describeEncoder(e.Encoder), e.Offset, e.Err)
+}
+
+func (e *UnpackError) Unwrap() error {
+	return e.Err
+}
+
+// PackError reports which element Message.Pack could not encode
+type PackError struct {
+	Field   int    // Top-level field number, or FieldMTI/FieldBitmap
+	Path    string // Dotted path down to the failing subfield, e.g., "127.22"
+	Offset  int    // Bytes already written before the failing element
+	Encoder string // Encoder type, e.g., "FANumeric"; empty for spec mismatches
+	Err     error
+}
+
+func (e *PackError) Error() string {
+	return fmt.Sprintf("pack %s%s at offset %d: %v", describePath(e.Path), describeEncoder(e.Encoder), e.Offset, e.Err)
+}
+
+func (e *PackError) Unwrap() error {
+	return e.Err
+}
+
+func newUnpackError(path string, offset int, encoder any, err error) *UnpackError {
+	return &UnpackError{
+		Field:   topField(path),
+		Path:    path,
+		Offset:  offset,
+		Encoder: encoderName(encoder),
+		Err:     err,
+	}
+}
+
+func newPackError(path string, offset int, encoder any, err error) *PackError {
+	return &PackError{
+		Field:   topField(path),
+		Path:    path,
+		Offset:  offset,
+		Encoder: encoderName(encoder),
+		Err:     err,
+	}
+}
+
+// attachRaw copies the payload bytes around the failure into the error
+func (e *UnpackError) attachRaw(data []byte) {
+	from := max(0, min(e.Offset, len(data))-rawContext)
+	to := min(len(data), e.Offset+rawContext)
+	e.Raw = append([]byte(nil), data[from:to]...)
+	e.RawFrom = from
+}
+
+func topField(path string) int {
+	head, _, _ := strings.Cut(path, ".")
+	n, _ := strconv.Atoi(head)
+	return n
+}
+
+func describePath(path string) string {
+	switch path {
+	case strconv.Itoa(FieldMTI):
+		return "MTI"
+	case strconv.Itoa(FieldBitmap):
+		return "bitmap"
+	}
+	return "field " + path
+}
+
+func describeEncoder(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " (" + name + ")"
+}
+
+// encoderName returns the type name of an encoder, e.g., "FBLLNumeric"
+func encoderName(encoder any) string {
+	if encoder == nil {
+		return ""
+	}
+	t := reflect.TypeOf(encoder)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,84 @@
+package iso8583
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnpackErrorPosition(t *testing.T) {
+	spec := testSpec()
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(11, "000123")
+	msg.SetPath("127.2", "KEY")
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		path    string
+		field   int
+		offset  int
+		encoder string
+	}{
+		{"short MTI", []byte("02"), "0", FieldMTI, 0, "FANumeric"},
+		{"short bitmap", packed[:10], "1", FieldBitmap, 4, "FABitmap"},
+		// MTI (4) + primary and secondary bitmap (32) + field 11 (6)
+		{"truncated field", packed[:40], "11", 11, 36, "FANumeric"},
+		// field 127: LLL (3) + sub-bitmap (16), then 127.2 with a broken LL
+		{"truncated subfield", append(append([]byte{}, packed[:42]...), []byte("017"+"4000000000000000"+"9")...), "127.2", 127, 61, "FALLChar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewMessage().Unpack(tt.data, spec)
+
+			var ue *UnpackError
+			if !errors.As(err, &ue) {
+				t.Fatalf("expected *UnpackError, got %T: %v", err, err)
+			}
+			if ue.Path != tt.path || ue.Field != tt.field || ue.Offset != tt.offset || ue.Encoder != tt.encoder {
+				t.Errorf("got path %s field %d offset %d encoder %s, want %s %d %d %s",
+					ue.Path, ue.Field, ue.Offset, ue.Encoder, tt.path, tt.field, tt.offset, tt.encoder)
+			}
+			if ue.Err == nil || len(ue.Raw) == 0 {
+				t.Errorf("expected cause and raw bytes, got %+v", ue)
+			}
+			if got := tt.data[ue.RawFrom : ue.RawFrom+len(ue.Raw)]; string(got) != string(ue.Raw) {
+				t.Errorf("Raw %q does not match payload at %d", ue.Raw, ue.RawFrom)
+			}
+		})
+	}
+}
+
+func TestPackError(t *testing.T) {
+	spec := testSpec()
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(11, "000123")
+	msg.SetPath("48.1", strings.Repeat("A", 100))
+	_, err := msg.Pack(spec)
+
+	var pe *PackError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *PackError, got %T: %v", err, err)
+	}
+	// The offset is the one of field 48: MTI (4) + bitmap (16) + field 11 (6)
+	if pe.Field != 48 || pe.Path != "48.1" || pe.Encoder != "FALLChar" || pe.Offset != 26 {
+		t.Errorf("unexpected error: %+v", pe)
+	}
+
+	msg = NewMessage()
+	msg.MTI = "0200"
+	msg.SetPath("48.7", "X")
+	_, err = msg.Pack(spec)
+	if !errors.As(err, &pe) || pe.Path != "48.7" || pe.Field != 48 {
+		t.Errorf("expected error on 48.7, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"iter"
+	"slices"
+)
+
+// MaxField is the highest field number a bitmap can announce (with a tertiary bitmap)
+const MaxField = 192
+
+// FieldTable holds values by field number. Numbers 1 to MaxField, all a
+// bitmap can address, live in a fixed array, so packing and unpacking cost
+// no hashing; others, which only tagged composites use, go to a map. The
+// zero value is empty and ready to use, and a nil table reads as empty.
+type FieldTable[T any] struct {
+	present field.Bits
+	slots   [MaxField + 1]T
+	extra   map[int]T
+	n       int
+}
+
+// Fields holds the fields of a message or composite field
+type Fields = FieldTable[*Field]
+
+// FieldSpecs holds the field definitions of a spec
+type FieldSpecs = FieldTable[FieldSpec]
+
+// NewFieldTable returns a table holding the entries of m
+func NewFieldTable[T any](m map[int]T) *FieldTable[T] {
+	t := &FieldTable[T]{}
+	for id, v := range m {
+		t.Set(id, v)
+	}
+	return t
+}
+
+func inArray(id int) bool {
+	return id >= 1 && id <= MaxField
+}
+
+// Get returns the value of field id, the zero value if absent
+func (t *FieldTable[T]) Get(id int) T {
+	v, _ := t.Lookup(id)
+	return v
+}
+
+// Lookup returns the value of field id and whether it is present
+func (t *FieldTable[T]) Lookup(id int) (T, bool) {
+	var zero T
+	if t == nil {
+		return zero, false
+	}
+	if inArray(id) {
+		if !t.present.Has(id) {
+			return zero, false
+		}
+		return t.slots[id], true
+	}
+	v, ok := t.extra[id]
+	return v, ok
+}
+
+// Has reports whether field id is present
+func (t *FieldTable[T]) Has(id int) bool {
+	_, ok := t.Lookup(id)
+	return ok
+}
+
+// Set stores v as field id
+func (t *FieldTable[T]) Set(id int, v T) {
+	if inArray(id) {
+		if !t.present.Has(id) {
+			t.present.Set(id)
+			t.n++
+		}
+		t.slots[id] = v
+		return
+	}
+	if t.extra == nil {
+		t.extra = make(map[int]T)
+	}
+	if _, ok := t.extra[id]; !ok {
+		t.n++
+	}
+	t.extra[id] = v
+}
+
+// Delete removes field id
+func (t *FieldTable[T]) Delete(id int) {
+	if t == nil {
+		return
+	}
+	if inArray(id) {
+		if t.present.Has(id) {
+			var zero T
+			t.present.Unset(id)
+			t.slots[id] = zero
+			t.n--
+		}
+		return
+	}
+	if _, ok := t.extra[id]; ok {
+		delete(t.extra, id)
+		t.n--
+	}
+}
+
+// Len returns the number of fields present
+func (t *FieldTable[T]) Len() int {
+	if t == nil {
+		return 0
+	}
+	return t.n
+}
+
+// All iterates over the fields present in ascending number
+func (t *FieldTable[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		if t == nil {
+			return
+		}
+		var extra []int
+		if len(t.extra) > 0 {
+			extra = make([]int, 0, len(t.extra))
+			for id := range t.extra {
+				extra = append(extra, id)
+			}
+			slices.Sort(extra)
+		}
+		// Numbers below 1 come before the array, above MaxField after it
+		for len(extra) > 0 && extra[0] < 1 {
+			if !yield(extra[0], t.extra[extra[0]]) {
+				return
+			}
+			extra = extra[1:]
+		}
+		for id := t.present.Next(0); id != 0; id = t.present.Next(id) {
+			if !yield(id, t.slots[id]) {
+				return
+			}
+		}
+		for _, id := range extra {
+			if !yield(id, t.extra[id]) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the numbers of the fields present in ascending order
+func (t *FieldTable[T]) Keys() []int {
+	keys := make([]int, 0, t.Len())
+	for id := range t.All() {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+// Last returns the highest field number present, 0 when empty
+func (t *FieldTable[T]) Last() int {
+	last := 0
+	for id := range t.All() {
+		last = id
+	}
+	return last
+}
+
+// Reset removes every field, touching only the slots in use
+func (t *FieldTable[T]) Reset() {
+	var zero T
+	for id := t.present.Next(0); id != 0; id = t.present.Next(id) {
+		t.slots[id] = zero
+	}
+	t.present = field.Bits{}
+	clear(t.extra)
+	t.n = 0
+}
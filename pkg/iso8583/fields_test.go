@@ -0,0 +1,39 @@
+package iso8583
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFieldTable(t *testing.T) {
+	var fields FieldTable[string]
+	for _, id := range []int{999, 127, 0, 2, 192, 1} {
+		fields.Set(id, "v")
+	}
+	fields.Set(2, "w")
+
+	// The array and the overflow map read as one, in field order
+	if got, want := fields.Keys(), []int{0, 1, 2, 127, 192, 999}; !slices.Equal(got, want) {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+	if fields.Len() != 6 || fields.Get(2) != "w" || fields.Last() != 999 {
+		t.Fatalf("len %d, field 2 %q, last %d", fields.Len(), fields.Get(2), fields.Last())
+	}
+
+	fields.Delete(127)
+	fields.Delete(999)
+	fields.Delete(64)
+	if _, ok := fields.Lookup(127); ok || fields.Has(999) || fields.Len() != 4 {
+		t.Errorf("after Delete: keys %v, len %d", fields.Keys(), fields.Len())
+	}
+
+	fields.Reset()
+	if fields.Len() != 0 || fields.Has(2) || fields.Has(0) {
+		t.Errorf("after Reset: keys %v", fields.Keys())
+	}
+
+	var none *Fields
+	if none.Len() != 0 || none.Get(2) != nil || none.Has(2) {
+		t.Error("nil table is not empty")
+	}
+}
@@ -2,6 +2,7 @@ package iso8583
 
 import (
 	"GoSwitch/pkg/field"
+	"bytes"
 	"testing"
 )
 
@@ -10,7 +11,7 @@ func TestRoundTrip(t *testing.T) {
 		MTIEncoder:    &field.FBNumeric{},
 		BitmapEncoder: &field.FBBitmap{},
 	}
-	spec.Fields = map[int]FieldSpec{
+	spec.Fields = *NewFieldTable(map[int]FieldSpec{
 		2: {
 			Length:      16,
 			Description: "Primary Account Number",
@@ -21,7 +22,7 @@ func TestRoundTrip(t *testing.T) {
 			Description: "Processing Code",
 			Encoder:     &field.FBNumeric{},
 		},
-	}
+	})
 	orig := NewMessage()
 	orig.MTI = "0200"
 	orig.Set(2, "12345678") // LLVAR
@@ -44,7 +45,64 @@ func TestRoundTrip(t *testing.T) {
 	if result.MTI != orig.MTI {
 		t.Errorf("MTI mismatch: got %s", result.MTI)
 	}
-	if string(result.Fields[3].Value) != "300000" {
-		t.Errorf("Field 3 mismatch: got %s", string(result.Fields[3].Value))
+	if string(result.Fields.Get(3).Value) != "400000" {
+		t.Errorf("Field 3 mismatch: got %s", string(result.Fields.Get(3).Value))
+	}
+}
+
+func TestTertiaryBitmap(t *testing.T) {
+	spec := &Spec{
+		MTIEncoder:    &field.FANumeric{},
+		BitmapEncoder: &field.FBBitmap{Tertiary: true},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			3:   {Length: 6, Description: "Processing Code", Encoder: &field.FANumeric{}},
+			100: {Length: 11, Description: "Receiving Institution", Encoder: &field.FALLNumeric{}},
+			150: {Length: 10, Description: "Private", Encoder: &field.FChar{}},
+		}),
+	}
+
+	orig := NewMessage()
+	orig.MTI = "0200"
+	orig.Set(3, "000000")
+	orig.Set(100, "123")
+	orig.Set(150, "TERTIARY")
+
+	packed, err := orig.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	// MTI + 24-byte bitmap + fields
+	if len(packed) != 4+24+6+5+10 {
+		t.Fatalf("unexpected packed length %d", len(packed))
+	}
+	if packed[4]&0x80 == 0 || packed[12]&0x80 == 0 {
+		t.Errorf("secondary/tertiary indicators not set: % X", packed[4:28])
+	}
+	if bitmap, err := orig.GenerateBitmapHex(); err != nil || !bytes.Equal(bitmap, packed[4:28]) {
+		t.Errorf("GenerateBitmapHex: got % X, %v; want % X", bitmap, err, packed[4:28])
+	}
+
+	result := NewMessage()
+	if err := result.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if result.Get(150) != "TERTIARY  " || result.Get(100) != "123" {
+		t.Errorf("unexpected fields: %s", result.LogString())
+	}
+	if result.Fields.Has(65) {
+		t.Error("tertiary indicator unpacked as field 65")
+	}
+
+	// Without the tertiary bitmap, field 150 is out of range
+	spec.BitmapEncoder = &field.FBBitmap{}
+	if _, err := orig.Pack(spec); err == nil {
+		t.Error("expected error for field 150 without tertiary bitmap")
+	}
+
+	// Field 65 cannot carry data once it is the tertiary indicator
+	spec.BitmapEncoder = &field.FBBitmap{Tertiary: true}
+	orig.Set(65, "1")
+	if _, err := orig.Pack(spec); err == nil {
+		t.Error("expected error for field 65 with tertiary bitmap")
 	}
 }
@@ -0,0 +1,215 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// jposClass maps a jPOS ISOFieldPackager class to the matching encoder
+type jposClass struct {
+	encoder      func() field.ISOField
+	class        field.Class
+	lengthFactor int // jPOS counts bytes where our hex-string encoders count digits
+}
+
+func registered(name string) func() field.ISOField {
+	return func() field.ISOField {
+		enc, _ := field.New(name)
+		return enc
+	}
+}
+
+func variable(prefix func() field.LengthPrefix, content func() field.Content) func() field.ISOField {
+	return func() field.ISOField {
+		return &field.Variable{Prefix: prefix(), Content: content()}
+	}
+}
+
+func asciiPrefix(digits int) func() field.LengthPrefix {
+	return func() field.LengthPrefix { return &field.ASCIIPrefix{Digits: digits} }
+}
+
+func bcdPrefix(digits int) func() field.LengthPrefix {
+	return func() field.LengthPrefix { return &field.BCDPrefix{Digits: digits} }
+}
+
+func binaryContent() field.Content {
+	return &field.BinaryContent{}
+}
+
+var jposClasses = map[string]jposClass{
+	"IFA_NUMERIC":    {encoder: registered("FANumeric"), class: field.ClassNumeric},
+	"IFB_NUMERIC":    {encoder: registered("FBNumeric"), class: field.ClassNumeric},
+	"IFE_NUMERIC":    {encoder: registered("FENumeric"), class: field.ClassNumeric},
+	"IF_CHAR":        {encoder: registered("FChar")},
+	"IFE_CHAR":       {encoder: registered("FEChar")},
+	"IFA_AMOUNT":     {encoder: registered("FChar"), class: field.ClassSignedAmount},
+	"IFA_LLNUM":      {encoder: registered("FALLNumeric"), class: field.ClassNumeric},
+	"IFA_LLLNUM":     {encoder: registered("FALLLNumeric"), class: field.ClassNumeric},
+	"IFB_LLNUM":      {encoder: registered("FBLLNumeric"), class: field.ClassNumeric},
+	"IFB_LLLNUM":     {encoder: registered("FBLLLNumeric"), class: field.ClassNumeric},
+	"IFE_LLNUM":      {encoder: registered("FELLNumeric"), class: field.ClassNumeric},
+	"IFA_LLCHAR":     {encoder: registered("FALLChar")},
+	"IFA_LLLCHAR":    {encoder: registered("FALLLChar")},
+	"IFA_LLLLCHAR":   {encoder: registered("FALLLLChar")},
+	"IFA_LLLLLLCHAR": {encoder: registered("FALLLLLLChar")},
+	"IFB_LLCHAR":     {encoder: registered("FBLLChar")},
+	"IFB_LLLCHAR":    {encoder: registered("FBLLLChar")},
+	"IFB_LLLLCHAR":   {encoder: registered("FBLLLLChar")},
+	"IFB_LLHCHAR":    {encoder: registered("FBLLHChar")},
+	"IFE_LLCHAR":     {encoder: registered("FELLChar")},
+	"IFE_LLLCHAR":    {encoder: registered("FELLLChar")},
+	"IFE_LLLLCHAR":   {encoder: registered("FELLLLChar")},
+	"IFA_BINARY":     {encoder: registered("FABinary"), class: field.ClassBinary, lengthFactor: 2},
+	"IFB_BINARY":     {encoder: registered("FBBinary"), class: field.ClassBinary, lengthFactor: 2},
+	"IFB_LLHBINARY":  {encoder: registered("FBLLHBinary"), class: field.ClassBinary},
+	"IFB_LLLHBINARY": {encoder: registered("FBLLLLHBinary"), class: field.ClassBinary},
+	"IFB_LLBINARY":   {encoder: variable(bcdPrefix(2), binaryContent), class: field.ClassBinary},
+	"IFB_LLLBINARY":  {encoder: variable(bcdPrefix(3), binaryContent), class: field.ClassBinary},
+	"IFA_LLBINARY":   {encoder: variable(asciiPrefix(2), binaryContent), class: field.ClassBinary},
+	"IFA_LLLBINARY":  {encoder: variable(asciiPrefix(3), binaryContent), class: field.ClassBinary},
+}
+
+// jposBitmap maps a jPOS bitmap class to a bitmap encoder. lengths holds the
+// length attributes it accepts and whether each declares a tertiary bitmap:
+// jPOS counts bytes (8, 16 or 24), though packagers of hex bitmaps may count digits.
+type jposBitmap struct {
+	name    string
+	lengths map[int]bool
+}
+
+var (
+	binaryBitmapLengths = map[int]bool{0: false, 8: false, 16: false, 24: true}
+	hexBitmapLengths    = map[int]bool{0: false, 8: false, 16: false, 24: true, 32: false, 48: true}
+)
+
+var jposBitmaps = map[string]jposBitmap{
+	"IFA_BITMAP": {name: "FABitmap", lengths: hexBitmapLengths},
+	"IFB_BITMAP": {name: "FBBitmap", lengths: binaryBitmapLengths},
+	"IFE_BITMAP": {name: "FEBitmap", lengths: hexBitmapLengths},
+}
+
+// jposField is an <isofield> or, with children, an <isofieldpackager> element
+type jposField struct {
+	ID         int         `xml:"id,attr"`
+	Length     int         `xml:"length,attr"`
+	Name       string      `xml:"name,attr"`
+	Class      string      `xml:"class,attr"`
+	Packager   string      `xml:"packager,attr"`
+	EmitBitmap bool        `xml:"emitBitmap,attr"`
+	Fields     []jposField `xml:"isofield"`
+	Packagers  []jposField `xml:"isofieldpackager"`
+}
+
+// LoadJPOSPackagerFile reads a jPOS GenericPackager XML file
+func LoadJPOSPackagerFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadJPOSPackager(data)
+}
+
+// LoadJPOSPackager builds a Spec from jPOS GenericPackager XML. Field 0 becomes
+// the MTI encoder, field 1 the bitmap, and <isofieldpackager> elements become
+// composites (sub-bitmapped with emitBitmap="true", positional otherwise).
+// Every jPOS class without an equivalent encoder is reported.
+func LoadJPOSPackager(data []byte) (*Spec, error) {
+	var root struct {
+		XMLName xml.Name `xml:"isopackager"`
+		jposField
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	spec := &Spec{}
+	buildJPOSFields(root.jposField, spec, "", &errs)
+
+	// Sections left out keep the LoadSpecFromFile defaults
+	if spec.MTIEncoder == nil {
+		spec.MTIEncoder = &field.FANumeric{}
+	}
+	if spec.BitmapEncoder == nil {
+		spec.BitmapEncoder = &field.FBBitmap{}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// buildJPOSFields converts the children of a packager element into the fields
+// of spec; the MTI (top level only) and bitmap children are set on spec instead
+func buildJPOSFields(parent jposField, spec *Spec, prefix string, errs *[]error) {
+	fail := func(id int, format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("field %s%d: %s", prefix, id, fmt.Sprintf(format, args...)))
+	}
+
+	for _, f := range append(parent.Fields, parent.Packagers...) {
+		class := strings.TrimPrefix(f.Class, "org.jpos.iso.")
+
+		if mapping, ok := jposBitmaps[class]; ok {
+			tertiary, ok := mapping.lengths[f.Length]
+			if !ok {
+				fail(f.ID, "unsupported length %d for jPOS class %s", f.Length, f.Class)
+				continue
+			}
+			bitmap, err := field.NewBitmap(mapping.name, tertiary)
+			if err != nil {
+				fail(f.ID, "%v", err)
+				continue
+			}
+			spec.BitmapEncoder = bitmap
+			continue
+		}
+
+		mapping, ok := jposClasses[class]
+		if !ok {
+			fail(f.ID, "unsupported jPOS class %s", f.Class)
+			continue
+		}
+
+		if prefix == "" && f.ID == 0 {
+			spec.MTIEncoder = mapping.encoder()
+			continue
+		}
+
+		fSpec := FieldSpec{
+			Length:      f.Length,
+			Description: f.Name,
+			Encoder:     mapping.encoder(),
+			Class:       mapping.class,
+		}
+		if mapping.lengthFactor > 0 {
+			fSpec.Length *= mapping.lengthFactor
+		}
+
+		if len(f.Fields) > 0 || len(f.Packagers) > 0 {
+			if strings.Contains(f.Packager, "Tagged") {
+				fail(f.ID, "unsupported jPOS sub-packager %s", f.Packager)
+				continue
+			}
+			sub := &Spec{}
+			buildJPOSFields(f, sub, fmt.Sprintf("%s%d.", prefix, f.ID), errs)
+			if !f.EmitBitmap {
+				sub.BitmapEncoder = nil
+			} else if sub.BitmapEncoder == nil {
+				fail(f.ID, "emitBitmap is set but no bitmap subfield is defined")
+			}
+			fSpec.Subfields = sub
+			fSpec.Class = ""
+		}
+
+		spec.Fields.Set(f.ID, fSpec)
+	}
+}
@@ -0,0 +1,118 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoadJPOSPackagerFile(t *testing.T) {
+	spec, err := LoadJPOSPackagerFile("testdata/jpos_iso87binary.xml")
+	if err != nil {
+		t.Fatalf("LoadJPOSPackagerFile failed: %v", err)
+	}
+
+	if _, ok := spec.MTIEncoder.(*field.FBNumeric); !ok {
+		t.Errorf("MTI encoder: got %T", spec.MTIEncoder)
+	}
+	if _, ok := spec.BitmapEncoder.(*field.FBBitmap); !ok {
+		t.Errorf("bitmap encoder: got %T", spec.BitmapEncoder)
+	}
+	if f := spec.Fields.Get(2); f.Description != "PAN - PRIMARY ACCOUNT NUMBER" || f.Class != field.ClassNumeric {
+		t.Errorf("field 2: got %+v", f)
+	}
+	// jPOS binary lengths are in bytes, ours in hex digits
+	if f := spec.Fields.Get(52); f.Length != 16 {
+		t.Errorf("field 52 length: got %d", f.Length)
+	}
+	if f := spec.Fields.Get(127); f.Subfields == nil || f.Subfields.BitmapEncoder == nil || f.Subfields.Fields.Len() != 2 {
+		t.Errorf("field 127: got %+v", f.Subfields)
+	}
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(2, "4111111111111111")
+	msg.Set(4, "1000")
+	msg.Set(28, "D00000100")
+	msg.Set(52, "0123456789ABCDEF")
+	msg.Set(55, "9F2701809F360200A5")
+	msg.SetPath("127.2", "KEY")
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	got := NewMessage()
+	if err := got.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if changes := Diff(msg, got); len(changes) != 1 || changes[0].Path != "4" {
+		// Only the zero padding of field 4 differs
+		t.Errorf("unexpected differences: %v", changes)
+	}
+}
+
+func TestLoadJPOSPackagerUnsupported(t *testing.T) {
+	_, err := LoadJPOSPackager([]byte(`<isopackager>
+  <isofield id="0" length="4" name="MTI" class="org.jpos.iso.IFA_NUMERIC"/>
+  <isofield id="1" length="16" name="BITMAP" class="org.jpos.iso.IFA_BITMAP"/>
+  <isofield id="2" length="19" name="PAN" class="org.jpos.iso.IFA_LLBNUM"/>
+  <isofield id="3" length="6" name="PROC" class="org.jpos.iso.IFA_NUMERIC"/>
+  <isofield id="60" length="999" name="X" class="org.jpos.iso.IF_TBASE"/>
+  <isofieldpackager id="48" length="999" name="TAGGED" class="org.jpos.iso.IFA_LLLCHAR"
+      packager="org.jpos.iso.packager.GenericTaggedFieldsPackager">
+    <isofield id="1" length="99" name="A" class="org.jpos.iso.IFA_LLCHAR"/>
+  </isofieldpackager>
+</isopackager>`))
+	if err == nil {
+		t.Fatal("expected error for unsupported classes")
+	}
+	for _, want := range []string{"field 2: unsupported jPOS class org.jpos.iso.IFA_LLBNUM", "field 60:", "field 48: unsupported jPOS sub-packager"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadJPOSPackagerTertiaryBitmap(t *testing.T) {
+	packager := func(class string, length int) []byte {
+		return []byte(fmt.Sprintf(`<isopackager>
+  <isofield id="0" length="4" name="MTI" class="org.jpos.iso.IFA_NUMERIC"/>
+  <isofield id="1" length="%d" name="BITMAP" class="org.jpos.iso.%s"/>
+  <isofield id="3" length="6" name="PROC" class="org.jpos.iso.IFA_NUMERIC"/>
+  <isofield id="150" length="10" name="PRIVATE" class="org.jpos.iso.IFA_LLCHAR"/>
+</isopackager>`, length, class))
+	}
+
+	for _, tc := range []struct {
+		class  string
+		length int
+	}{{"IFB_BITMAP", 24}, {"IFA_BITMAP", 24}, {"IFA_BITMAP", 48}} {
+		spec, err := LoadJPOSPackager(packager(tc.class, tc.length))
+		if err != nil {
+			t.Fatalf("%s length %d: %v", tc.class, tc.length, err)
+		}
+		if n := spec.BitmapEncoder.MaxField(); n != 192 {
+			t.Errorf("%s length %d: bitmap carries %d fields", tc.class, tc.length, n)
+		}
+
+		msg := NewMessage()
+		msg.MTI = "0200"
+		msg.Set(3, "000000")
+		msg.Set(150, "TERTIARY")
+		packed, err := msg.Pack(spec)
+		if err != nil {
+			t.Fatalf("%s length %d: Pack failed: %v", tc.class, tc.length, err)
+		}
+		got := NewMessage()
+		if err := got.Unpack(packed, spec); err != nil || got.Get(150) != "TERTIARY" {
+			t.Errorf("%s length %d: field 150 %q, %v", tc.class, tc.length, got.Get(150), err)
+		}
+	}
+
+	_, err := LoadJPOSPackager(packager("IFB_BITMAP", 12))
+	if err == nil || !strings.Contains(err.Error(), "field 1: unsupported length 12") {
+		t.Errorf("expected unsupported bitmap length, got %v", err)
+	}
+}
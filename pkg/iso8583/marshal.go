@@ -0,0 +1,312 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default time layouts for the standard date/time fields; other time.Time
+// fields need an explicit layout, e.g., `iso8583:"73,layout=060102"`
+var defaultTimeLayouts = map[int]string{
+	7:  "0102150405", // Transmission date & time (MMDDhhmmss)
+	12: "150405",     // Time, local transaction (hhmmss)
+	13: "0102",       // Date, local transaction (MMDD)
+	14: "0601",       // Date, expiration (YYMM)
+	15: "0102",       // Date, settlement (MMDD)
+	16: "0102",       // Date, conversion (MMDD)
+	17: "0102",       // Date, capture (MMDD)
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	tlvType  = reflect.TypeOf([]field.TLV{})
+)
+
+// tagInfo is the parsed form of an `iso8583:"..."` struct tag
+type tagInfo struct {
+	mti    bool
+	field  int
+	layout string
+}
+
+// parseStructTag parses the tag of a message field or, inside a composite
+// (nested is set), of a subfield; field 1 is the bitmap of a message only
+func parseStructTag(tag string, nested bool) (tagInfo, error) {
+	parts := strings.Split(tag, ",")
+
+	var info tagInfo
+	if parts[0] == "mti" {
+		info.mti = true
+	} else {
+		lowest := 2
+		if nested {
+			lowest = 1
+		}
+		n, err := strconv.Atoi(parts[0])
+		if err != nil || n < lowest {
+			return info, fmt.Errorf("invalid iso8583 tag %q", tag)
+		}
+		info.field = n
+	}
+
+	for _, opt := range parts[1:] {
+		key, val, _ := strings.Cut(opt, "=")
+		switch key {
+		case "layout":
+			info.layout = val
+		default:
+			return info, fmt.Errorf("unknown option %q in iso8583 tag %q", key, tag)
+		}
+	}
+	// The default layouts are those of message fields, not of subfields
+	if info.layout == "" && !nested {
+		info.layout = defaultTimeLayouts[info.field]
+	}
+	return info, nil
+}
+
+// Marshal builds a message from a struct whose fields carry `iso8583:"<n>"`
+// tags (and optionally `iso8583:"mti"`). Supported types are strings, integers,
+// time.Time, []field.TLV, nested structs for composite fields, and pointers to
+// these; nil pointers leave the field out. When spec is given, every tagged
+// field must be defined in it.
+func Marshal(v any, spec *Spec) (*Message, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("iso8583: Marshal(nil)")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("iso8583: Marshal expects a struct, got %s", rv.Type())
+	}
+
+	if spec != nil && len(spec.Versions) > 0 {
+		// The version spec depends on the MTI, so read it in a first pass
+		probe := NewMessage()
+		if err := marshalStruct(rv, &probe.Fields, &probe.MTI, nil, ""); err != nil {
+			return nil, err
+		}
+		vs, err := spec.forMTI(probe.MTI)
+		if err != nil {
+			return nil, fmt.Errorf("iso8583: %w", err)
+		}
+		spec = vs
+	}
+
+	msg := NewMessage()
+	if err := marshalStruct(rv, &msg.Fields, &msg.MTI, spec, ""); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func marshalStruct(rv reflect.Value, fields *Fields, mti *string, spec *Spec, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("iso8583")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		info, err := parseStructTag(tag, prefix != "")
+		if err != nil {
+			return err
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		if info.mti {
+			if mti == nil || fv.Kind() != reflect.String {
+				return fmt.Errorf("iso8583: %s: mti must be a top-level string field", sf.Name)
+			}
+			*mti = fv.String()
+			continue
+		}
+
+		path := fmt.Sprintf("%s%d", prefix, info.field)
+		var fSpec FieldSpec
+		if spec != nil {
+			if fSpec, ok = spec.Fields.Lookup(info.field); !ok {
+				return fmt.Errorf("iso8583: %s: field %s is not defined in spec", sf.Name, path)
+			}
+		}
+
+		f, err := marshalValue(fv, info, fSpec, spec != nil, path)
+		if err != nil {
+			return fmt.Errorf("iso8583: %s: %w", sf.Name, err)
+		}
+		fields.Set(info.field, f)
+	}
+	return nil
+}
+
+func marshalValue(fv reflect.Value, info tagInfo, fSpec FieldSpec, checkSpec bool, path string) (*Field, error) {
+	switch {
+	case fv.Type() == timeType:
+		if info.layout == "" {
+			return nil, fmt.Errorf("field %s: time.Time needs a layout option", path)
+		}
+		return &Field{Value: []byte(fv.Interface().(time.Time).Format(info.layout))}, nil
+
+	case fv.Type() == tlvType:
+		val, err := encodeTags(fv.Interface().([]field.TLV))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", path, err)
+		}
+		return &Field{Value: []byte(val)}, nil
+
+	case fv.Kind() == reflect.Struct:
+		if checkSpec && fSpec.Subfields == nil {
+			return nil, fmt.Errorf("field %s is not a composite in spec", path)
+		}
+		f := &Field{Subfields: &Fields{}}
+		if err := marshalStruct(fv, f.Subfields, nil, fSpec.Subfields, path+"."); err != nil {
+			return nil, err
+		}
+		return f, nil
+
+	case fv.Kind() == reflect.String:
+		return &Field{Value: []byte(fv.String())}, nil
+
+	case fv.CanInt():
+		n := fv.Int()
+		if n < 0 {
+			return nil, fmt.Errorf("field %s: negative value %d", path, n)
+		}
+		return &Field{Value: []byte(strconv.FormatInt(n, 10))}, nil
+
+	case fv.CanUint():
+		return &Field{Value: []byte(strconv.FormatUint(fv.Uint(), 10))}, nil
+	}
+
+	return nil, fmt.Errorf("field %s: unsupported type %s", path, fv.Type())
+}
+
+// Unmarshal fills a tagged struct (see Marshal) from a message. Fields absent
+// from the message leave zero values, or nil for pointers.
+func Unmarshal(msg *Message, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("iso8583: Unmarshal expects a non-nil pointer, got %T", v)
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("iso8583: Unmarshal expects a pointer to a struct, got %T", v)
+	}
+
+	mti := msg.MTI
+	return unmarshalStruct(rv, &msg.Fields, &mti, "")
+}
+
+func unmarshalStruct(rv reflect.Value, fields *Fields, mti *string, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("iso8583")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		info, err := parseStructTag(tag, prefix != "")
+		if err != nil {
+			return err
+		}
+
+		fv := rv.Field(i)
+		if info.mti {
+			if mti == nil {
+				return fmt.Errorf("iso8583: %s: mti must be a top-level string field", sf.Name)
+			}
+			if err := setString(fv, *mti); err != nil {
+				return fmt.Errorf("iso8583: %s: %w", sf.Name, err)
+			}
+			continue
+		}
+
+		f, ok := fields.Lookup(info.field)
+		if !ok {
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+
+		path := fmt.Sprintf("%s%d", prefix, info.field)
+		if err := unmarshalValue(fv, f, info, path); err != nil {
+			return fmt.Errorf("iso8583: %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(fv reflect.Value, f *Field, info tagInfo, path string) error {
+	val := string(f.Value)
+
+	switch {
+	case fv.Type() == timeType:
+		if info.layout == "" {
+			return fmt.Errorf("field %s: time.Time needs a layout option", path)
+		}
+		t, err := time.Parse(info.layout, val)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
+		}
+		fv.Set(reflect.ValueOf(t))
+
+	case fv.Type() == tlvType:
+		tags, err := decodeTags(f.Value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
+		}
+		fv.Set(reflect.ValueOf(tags))
+
+	case fv.Kind() == reflect.Struct:
+		return unmarshalStruct(fv, f.Subfields, nil, path+".")
+
+	case fv.Kind() == reflect.String:
+		fv.SetString(val)
+
+	case fv.CanInt():
+		n, err := strconv.ParseInt(strings.TrimSpace(val), 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("field %s: invalid integer %q", path, val)
+		}
+		fv.SetInt(n)
+
+	case fv.CanUint():
+		n, err := strconv.ParseUint(strings.TrimSpace(val), 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("field %s: invalid integer %q", path, val)
+		}
+		fv.SetUint(n)
+
+	default:
+		return fmt.Errorf("field %s: unsupported type %s", path, fv.Type())
+	}
+	return nil
+}
+
+func setString(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		fv.Set(reflect.New(fv.Type().Elem()))
+		fv = fv.Elem()
+	}
+	if fv.Kind() != reflect.String {
+		return fmt.Errorf("mti must be a string, got %s", fv.Type())
+	}
+	fv.SetString(s)
+	return nil
+}
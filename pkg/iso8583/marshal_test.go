@@ -0,0 +1,204 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"testing"
+	"time"
+)
+
+type privateData struct {
+	SwitchKey string  `iso8583:"2"`
+	Routing   *string `iso8583:"3"`
+}
+
+type purchase struct {
+	MTI          string       `iso8583:"mti"`
+	PAN          string       `iso8583:"2"`
+	ProcCode     string       `iso8583:"3"`
+	Amount       int64        `iso8583:"4"`
+	Transmission time.Time    `iso8583:"7"`
+	STAN         int          `iso8583:"11"`
+	LocalTime    time.Time    `iso8583:"12"`
+	LocalDate    time.Time    `iso8583:"13"`
+	RRN          *string      `iso8583:"37"`
+	Terminal     string       `iso8583:"41"`
+	ICC          []field.TLV  `iso8583:"55"`
+	Private      *privateData `iso8583:"127"`
+	Internal     string       // untagged fields are ignored
+}
+
+func marshalSpec() *Spec {
+	return withFields(testSpec(), map[int]FieldSpec{
+		2:  {Length: 19, Encoder: &field.FALLNumeric{}},
+		3:  {Length: 6, Encoder: &field.FANumeric{}},
+		4:  {Length: 12, Encoder: &field.FANumeric{}},
+		7:  {Length: 10, Encoder: &field.FANumeric{}},
+		12: {Length: 6, Encoder: &field.FANumeric{}},
+		13: {Length: 4, Encoder: &field.FANumeric{}},
+		37: {Length: 12, Encoder: &field.FChar{}},
+		41: {Length: 8, Encoder: &field.FChar{}},
+		55: {Length: 255, Encoder: &field.FBLLLTLV{}},
+	})
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	spec := marshalSpec()
+	ts := time.Date(0, 10, 18, 14, 30, 5, 0, time.UTC)
+
+	in := purchase{
+		MTI:          "0200",
+		PAN:          "4111111111111111",
+		ProcCode:     "000000",
+		Amount:       1050,
+		Transmission: ts,
+		STAN:         42,
+		LocalTime:    ts,
+		LocalDate:    ts,
+		Terminal:     "TERM01",
+		ICC:          []field.TLV{{Tag: "9F27", Value: []byte{0x80}}, {Tag: "95", Value: []byte{0, 0, 0, 0x80, 0}}},
+		Private:      &privateData{SwitchKey: "KEY"},
+		Internal:     "not packed",
+	}
+
+	msg, err := Marshal(&in, spec)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if msg.Get(4) != "1050" || msg.Get(7) != "1018143005" || msg.Get(12) != "143005" || msg.Get(13) != "1018" {
+		t.Errorf("unexpected fields: %s", msg.LogString())
+	}
+	if msg.Fields.Has(37) {
+		t.Error("nil pointer field 37 should be left out")
+	}
+	if msg.GetPath("127.2") != "KEY" {
+		t.Errorf("127.2: got %q", msg.GetPath("127.2"))
+	}
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if !bytes.Contains(packed, []byte("000000001050")) {
+		t.Errorf("amount not zero padded on the wire: %q", packed)
+	}
+
+	received := NewMessage()
+	if err := received.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+
+	var out purchase
+	if err := Unmarshal(received, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out.MTI != "0200" || out.Amount != 1050 || out.STAN != 42 || out.PAN != in.PAN {
+		t.Errorf("unexpected struct: %+v", out)
+	}
+	if !out.Transmission.Equal(ts) || out.LocalTime.Hour() != 14 || out.LocalDate.Day() != 18 {
+		t.Errorf("unexpected times: %v %v %v", out.Transmission, out.LocalTime, out.LocalDate)
+	}
+	if out.RRN != nil {
+		t.Errorf("RRN should stay nil, got %q", *out.RRN)
+	}
+	if out.Terminal != "TERM01  " {
+		t.Errorf("Terminal: got %q", out.Terminal)
+	}
+	if len(out.ICC) != 2 || out.ICC[1].Tag != "95" {
+		t.Errorf("ICC: got %+v", out.ICC)
+	}
+	if out.Private == nil || out.Private.SwitchKey != "KEY" || out.Private.Routing != nil {
+		t.Errorf("Private: got %+v", out.Private)
+	}
+}
+
+// Subfield 1 is valid inside composites, unlike message field 1 (the bitmap)
+func TestMarshalSubfieldOne(t *testing.T) {
+	type additional struct {
+		Merchant string `iso8583:"1"`
+		ECI      int    `iso8583:"42"`
+	}
+	type terminal struct {
+		Batch  int    `iso8583:"1"`
+		Serial string `iso8583:"2"`
+	}
+	type request struct {
+		MTI        string     `iso8583:"mti"`
+		Additional additional `iso8583:"48"`
+		Terminal   terminal   `iso8583:"60"`
+	}
+	spec := testSpec()
+
+	msg, err := Marshal(request{MTI: "0200", Additional: additional{"SHOP", 5}, Terminal: terminal{12, "SN000001"}}, spec)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if msg.GetPath("48.1") != "SHOP" || msg.GetPath("60.1") != "12" {
+		t.Errorf("48.1 %q, 60.1 %q", msg.GetPath("48.1"), msg.GetPath("60.1"))
+	}
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	received := NewMessage()
+	if err := received.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	var out request
+	if err := Unmarshal(received, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out.Additional.Merchant != "SHOP" || out.Additional.ECI != 5 || out.Terminal.Batch != 12 || out.Terminal.Serial != "SN000001" {
+		t.Errorf("unexpected struct: %+v", out)
+	}
+
+	type bitmapField struct {
+		Bitmap string `iso8583:"1"`
+	}
+	if _, err := Marshal(bitmapField{"X"}, nil); err == nil {
+		t.Error("expected error for message field 1")
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	spec := marshalSpec()
+
+	type unknownField struct {
+		Data string `iso8583:"98"`
+	}
+	if _, err := Marshal(unknownField{Data: "X"}, spec); err == nil {
+		t.Error("expected error for field missing from spec")
+	}
+
+	type badTag struct {
+		Data string `iso8583:"four"`
+	}
+	if _, err := Marshal(badTag{}, nil); err == nil {
+		t.Error("expected error for invalid tag")
+	}
+
+	type negative struct {
+		Amount int64 `iso8583:"4"`
+	}
+	if _, err := Marshal(negative{Amount: -1}, spec); err == nil {
+		t.Error("expected error for negative amount")
+	}
+
+	type noLayout struct {
+		When time.Time `iso8583:"41"`
+	}
+	if _, err := Marshal(noLayout{}, spec); err == nil {
+		t.Error("expected error for time.Time without layout")
+	}
+
+	msg := NewMessage()
+	msg.Set(4, "12AB")
+	var out negative
+	if err := Unmarshal(msg, &out); err == nil {
+		t.Error("expected error for non-numeric amount")
+	}
+	if err := Unmarshal(msg, out); err == nil {
+		t.Error("expected error for non-pointer target")
+	}
+}
@@ -0,0 +1,128 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"strings"
+	"testing"
+)
+
+func TestMaskApply(t *testing.T) {
+	tests := []struct {
+		mask Mask
+		val  string
+		want string
+	}{
+		{MaskPAN, "4111111111111111", "411111******1111"},
+		{MaskPAN, "4111111111111111111", "411111*********1111"},
+		{MaskPAN, "411111111111", redacted},
+		{MaskRedact, "4111111111111111=2512", redacted},
+		{MaskNone, "00", "00"},
+		{"", "00", "00"},
+	}
+	for _, tt := range tests {
+		if got := tt.mask.Apply(tt.val); got != tt.want {
+			t.Errorf("%q.Apply(%q) = %q, want %q", tt.mask, tt.val, got, tt.want)
+		}
+	}
+
+	h := MaskHash.Apply("123456")
+	if !strings.HasPrefix(h, "sha256:") || h != MaskHash.Apply("123456") || h == MaskHash.Apply("123457") {
+		t.Errorf("unexpected hash %q", h)
+	}
+}
+
+func TestMaskFor(t *testing.T) {
+	spec := &Spec{
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			2:  {Mask: MaskNone},
+			37: {Mask: MaskHash},
+			48: {Subfields: &Spec{Fields: *NewFieldTable(map[int]FieldSpec{
+				5: {Mask: MaskRedact},
+			})}},
+			55: {Subfields: &Spec{Fields: *NewFieldTable(map[int]FieldSpec{1: {}})}},
+		}),
+	}
+
+	for path, want := range map[string]Mask{
+		"2":    MaskNone,   // Default overridden
+		"35":   MaskRedact, // Default, not in spec
+		"37":   MaskHash,
+		"48.5": MaskRedact,
+		"48.6": "",
+		"55.1": MaskRedact, // Inherited from the default for 55
+	} {
+		if got := spec.MaskFor(path); got != want {
+			t.Errorf("MaskFor(%s) = %q, want %q", path, got, want)
+		}
+	}
+
+	var none *Spec
+	if none.MaskFor("2") != MaskPAN {
+		t.Errorf("nil spec should apply the defaults")
+	}
+	spec.LogClear = true
+	if spec.MaskFor("35") != MaskNone {
+		t.Errorf("LogClear should disable masking")
+	}
+}
+
+func TestLogStringMasksSensitiveFields(t *testing.T) {
+	spec := &Spec{
+		MTIEncoder:    &field.FANumeric{},
+		BitmapEncoder: &field.FBBitmap{},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			2:  {Length: 19, Encoder: &field.FALLNumeric{}},
+			35: {Length: 37, Encoder: &field.FALLChar{}},
+			39: {Length: 2, Encoder: &field.FChar{}},
+		}),
+	}
+	msg := NewMessage()
+	msg.MTI = "0210"
+	msg.Set(2, "4111111111111111")
+	msg.Set(35, "4111111111111111=2512101")
+	msg.Set(39, "00")
+
+	want := "F0: 0210, F2: 411111******1111, F35: [REDACTED], F39: 00"
+	if got := msg.LogString(); got != want {
+		t.Errorf("LogString() = %q, want %q", got, want)
+	}
+
+	// Unpacked messages use their spec
+	data, _ := msg.Pack(spec)
+	unpacked := NewMessage()
+	if err := unpacked.Unpack(data, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if got := unpacked.LogString(); got != want {
+		t.Errorf("unpacked LogString() = %q, want %q", got, want)
+	}
+
+	spec.LogClear = true
+	if got := msg.LogStringWith(spec); !strings.Contains(got, "F2: 4111111111111111") {
+		t.Errorf("LogClear still masks: %q", got)
+	}
+	if out := Dump(data, spec); !strings.Contains(out, "34 31 31 31") {
+		t.Errorf("LogClear still masks the dump:\n%s", out)
+	}
+}
+
+func TestLoadSpecMask(t *testing.T) {
+	spec, err := LoadSpec([]byte(`
+fields:
+  37: {length: 12, encoder: FChar, mask: hash}
+`))
+	if err != nil {
+		t.Fatalf("LoadSpec failed: %v", err)
+	}
+	if spec.Fields.Get(37).Mask != MaskHash {
+		t.Errorf("mask not loaded: %q", spec.Fields.Get(37).Mask)
+	}
+
+	_, err = LoadSpec([]byte(`
+fields:
+  37: {length: 12, encoder: FChar, mask: scramble}
+`))
+	if err == nil || !strings.Contains(err.Error(), "field 37: unknown mask: scramble") {
+		t.Errorf("expected unknown mask error, got %v", err)
+	}
+}
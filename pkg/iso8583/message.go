@@ -1,16 +1,59 @@
 package iso8583
 
 import (
+	"GoSwitch/pkg/field"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"sort"
+	"strconv"
 	"strings"
 )
 
 // Field represents a single ISO 8583 data element
 type Field struct {
 	Value []byte
+	// Subfields holds the parsed content of a composite field (e.g., 127.22).
+	// When present, Pack rebuilds the field value from them.
+	Subfields *Fields
+	// Raw holds the wire bytes the field was unpacked from, length prefix
+	// included. Pack re-emits them unchanged while the field is untouched,
+	// so forwarded messages stay byte-exact (e.g., for MAC verification).
+	Raw []byte
+
+	dirty    bool
+	unpacked string // Value as decoded, to catch direct edits
+	str      string // Value as a string, to spare conversions while it is unchanged
+	spec     *Spec  // Spec the field was unpacked with
+}
+
+// text returns Value as a string, converting only after it was edited
+func (f *Field) text() string {
+	if string(f.Value) == f.str {
+		return f.str
+	}
+	return string(f.Value)
+}
+
+// MarkDirty forces the field to be re-encoded on the next Pack. Set, SetPath
+// and direct changes to Value are detected; call it after editing Subfields
+// by hand.
+func (f *Field) MarkDirty() {
+	f.dirty = true
+}
+
+// Dirty reports whether Pack has to re-encode the field rather than re-emit Raw
+func (f *Field) Dirty() bool {
+	if f.Raw == nil || f.dirty || string(f.Value) != f.unpacked {
+		return true
+	}
+	for _, sub := range f.Subfields.All() {
+		if sub.Dirty() {
+			return true
+		}
+	}
+	return false
 }
 
 // Message is the main ISO 8583 container
@@ -18,32 +61,68 @@ type Message struct {
 	Header []byte
 	MTI    string
 	Bitmap []byte
-	Fields map[int]*Field
+	Fields Fields // Indexed by field number; see FieldTable
+
+	spec  *Spec // Spec the message was unpacked with
+	arena arena // Memory of unpacked fields, reused after Reset
 }
 
 func NewMessage() *Message {
-	return &Message{
-		Fields: make(map[int]*Field),
+	return &Message{}
+}
+
+// Clone returns a deep copy of the message, safe to modify while other
+// goroutines hold the original
+func (m *Message) Clone() *Message {
+	c := &Message{
+		Header: bytes.Clone(m.Header),
+		MTI:    m.MTI,
+		Bitmap: bytes.Clone(m.Bitmap),
+		spec:   m.spec,
+	}
+	cloneFields(&c.Fields, &m.Fields)
+	return c
+}
+
+// cloneFields deep-copies the fields of src into dst
+func cloneFields(dst, src *Fields) {
+	for k, f := range src.All() {
+		dst.Set(k, f.Clone())
 	}
 }
 
+// Clone returns a deep copy of the field, including its subfields and raw bytes
+func (f *Field) Clone() *Field {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	c.Value = bytes.Clone(f.Value)
+	c.Raw = bytes.Clone(f.Raw)
+	if f.Subfields != nil {
+		c.Subfields = &Fields{}
+		cloneFields(c.Subfields, f.Subfields)
+	}
+	return &c
+}
+
 // Set adds a field to the message
 func (m *Message) Set(fieldNum int, value string) {
-	m.Fields[fieldNum] = &Field{Value: []byte(value)}
+	m.Fields.Set(fieldNum, &Field{Value: []byte(value), str: value})
 }
 
 // Get retrieves a field's value from the message
 func (m *Message) Get(fieldNum int) string {
-	field, exists := m.Fields[fieldNum]
+	field, exists := m.Fields.Lookup(fieldNum)
 	if !exists {
 		return ""
 	}
-	return string(field.Value)
+	return field.text()
 }
 
 // Unset removes a field from the message
 func (m *Message) Unset(fieldNum int) {
-	delete(m.Fields, fieldNum)
+	m.Fields.Delete(fieldNum)
 }
 
 // SetHeader sets the ISO header
@@ -57,173 +136,142 @@ func (m *Message) GetHeader() []byte {
 
 // ResponseMTI updates the message MTI to its response equivalent (e.g., 0200 -> 0210)
 func (m *Message) ResponseMTI() error {
-	if len(m.MTI) != 4 {
-		return fmt.Errorf("MTI must be 4 characters long")
-	}
-
-	mti := []byte(m.MTI)
-	switch mti[2] {
-	case '0':
-		mti[2] = '1'
-	case '2':
-		mti[2] = '3'
-	case '4':
-		mti[2] = '5'
-	default:
-		return fmt.Errorf("cannot convert MTI %s to response", m.MTI)
-	}
-	m.MTI = string(mti)
+	resp, err := ResponseMTI(m.MTI)
+	if err != nil {
+		return err
+	}
+	m.MTI = resp
 	return nil
 }
 
-// LogString formats the message fields for logging
-// Format: F0: 0200, F2: 000000001000, F39: 00
+// LogString formats the message fields for logging, masking sensitive values
+// with the spec the message was unpacked with (or the default masks)
+// Format: F0: 0200, F2: 411111******1111, F39: 00
 func (m *Message) LogString() string {
+	return m.LogStringWith(m.spec)
+}
+
+// LogStringWith is LogString with the masks of spec; composites with
+// subfields are listed one subfield at a time (F127.2: ...)
+func (m *Message) LogStringWith(spec *Spec) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("F0: %s", m.MTI))
 
-	// Sort keys to ensure consistent order
-	var keys []int
-	for k := range m.Fields {
-		keys = append(keys, k)
-	}
-	sort.Ints(keys)
-
-	for _, k := range keys {
-		if val := m.Fields[k]; val != nil {
-			sb.WriteString(fmt.Sprintf(", F%d: %s", k, string(val.Value)))
-		}
+	if spec != nil && !spec.LogClear {
+		// Fall back to the defaults when the version has no spec
+		spec, _ = spec.forMTI(m.MTI)
 	}
+	writeLogFields(&sb, &m.Fields, spec, "")
 	return sb.String()
 }
 
-// GenerateBitmapHex constructs the binary bitmap (8 or 16 bytes)
-func (m *Message) GenerateBitmapHex() ([]byte, error) {
-	maxField := 0
-	for fieldNum := range m.Fields {
-		if fieldNum > maxField {
-			maxField = fieldNum
+func writeLogFields(sb *strings.Builder, fields *Fields, spec *Spec, prefix string) {
+	for k, val := range fields.All() {
+		if val == nil {
+			continue
 		}
+		path := prefix + strconv.Itoa(k)
+		if val.Subfields.Len() > 0 {
+			writeLogFields(sb, val.Subfields, spec, path+".")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(", F%s: %s", path, spec.MaskValue(path, string(val.Value))))
 	}
+}
 
-	// Determine if we need a secondary bitmap (fields 65-128)
-	size := 8
-	if maxField > 64 {
-		size = 16
-	}
-
-	bitmap := make([]byte, size)
-
-	// If expanding to 16 bytes, the first bit (Field 1) MUST be set
-	if size == 16 {
-		bitmap[0] |= 0x80
-	}
-
-	for fieldNum := range m.Fields {
-		if fieldNum < 1 {
+// GenerateBitmapHex constructs the binary bitmap (8, 16 or 24 bytes) announcing
+// the message fields, the way Pack does with a binary bitmap encoder
+func (m *Message) GenerateBitmapHex() ([]byte, error) {
+	encoder := &field.FBBitmap{Tertiary: m.Fields.Last() > 128}
+	var present field.Bits
+	for k := range m.Fields.All() {
+		if k <= 1 {
 			continue
 		}
-		// Assuming we support up to 128 for now
-		if fieldNum > 128 {
-			continue
+		if k > encoder.MaxField() {
+			return nil, fmt.Errorf("field %d exceeds bitmap capacity of %d fields", k, encoder.MaxField())
 		}
-
-		byteIdx := (fieldNum - 1) / 8
-		bitIdx := uint(7 - ((fieldNum - 1) % 8))
-
-		bitmap[byteIdx] |= (1 << bitIdx)
+		present.Set(k)
 	}
-
-	return bitmap, nil
+	return appendBitmap(nil, present, encoder)
 }
 
+// packBufferSize is the initial capacity of Pack output, enough for most messages
+const packBufferSize = 512
+
+// Pack encodes the message; failures are reported as *PackError
 func (m *Message) Pack(spec *Spec) ([]byte, error) {
-	var buf bytes.Buffer
+	return m.AppendPack(make([]byte, 0, packBufferSize), spec)
+}
+
+// AppendPack is Pack appending to dst, so that a buffer can be reused from
+// one message to the next (e.g., AppendPack(buf[:0], spec))
+func (m *Message) AppendPack(dst []byte, spec *Spec) ([]byte, error) {
+	start := len(dst)
 
 	// 1. Pack MTI
 	// We use the encoder defined in the spec to handle ASCII/Binary MTI
-	mtiBytes, err := spec.MTIEncoder.Pack(m.MTI, 4)
+	dst, err := appendPacked(dst, spec.MTIEncoder, m.MTI, 4)
 	if err != nil {
-		return nil, err
+		return nil, newPackError("0", 0, spec.MTIEncoder, err)
 	}
-	buf.Write(mtiBytes)
 
-	// 2. Pack Bitmap - No more manual bit manipulation here!
-	presentFields := make(map[int]bool)
-	for k := range m.Fields {
-		presentFields[k] = true
+	// 2. Pack Bitmap and Fields, using the spec of the MTI version if there are several
+	spec, err = spec.forMTI(m.MTI)
+	if err != nil {
+		return nil, newPackError("0", 0, nil, err)
 	}
 
-	bitmapB, err := spec.BitmapEncoder.Pack(presentFields)
-	if err != nil {
-		return nil, err
-	}
-	buf.Write(bitmapB)
-
-	// 3. Pack Fields
-	// We loop based on the Spec to ensure we only pack what's allowed
-	for i := 2; i <= 128; i++ {
-		if fData, ok := m.Fields[i]; ok {
-			fSpec, ok := spec.Fields[i]
-			if !ok {
-				return nil, fmt.Errorf("field %d present in message but not in spec", i)
-			}
-			packed, err := fSpec.Encoder.Pack(string(fData.Value), fSpec.Length)
-			if err != nil {
-				return nil, err
-			}
-			buf.Write(packed)
-		}
+	// A received bitmap is kept when repacking with the same spec
+	var rawBitmap []byte
+	if spec == m.spec {
+		rawBitmap = m.Bitmap
 	}
-	return buf.Bytes(), nil
+	return packBitmapped(dst, start, &m.Fields, spec, "", rawBitmap)
 }
 
+// Unpack decodes data into the message; failures are reported as *UnpackError
+// carrying the offset and surrounding bytes of the element that broke
 func (m *Message) Unpack(data []byte, spec *Spec) error {
+	if err := m.unpack(data, spec); err != nil {
+		var ue *UnpackError
+		if errors.As(err, &ue) {
+			ue.attachRaw(data)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Message) unpack(data []byte, spec *Spec) error {
 	offset := 0
+	// Fields refer into one copy of the payload rather than each holding their own
+	data = m.arena.copy(data)
 
 	// 1. Unpack MTI
 	mti, readLen, err := spec.MTIEncoder.Unpack(data[offset:], 4)
 	if err != nil {
-		return err
+		return newUnpackError("0", offset, spec.MTIEncoder, err)
 	}
 	m.MTI = mti
-	offset += readLen
 	slog.Debug("Unpacked MTI", "mti", m.MTI)
 
-	// 2. Unpack Bitmap using the specialized BitMap interface
-	// This now returns a map[int]bool directly!
-	presentFields, readLen, err := spec.BitmapEncoder.Unpack(data[offset:])
-	if err != nil {
-		return err
+	top := spec
+	if spec, err = spec.forMTI(mti); err != nil {
+		return newUnpackError("0", offset, top.MTIEncoder, err)
 	}
-	// Store raw bytes for historical/debug purposes
-	m.Bitmap = data[offset : offset+readLen]
 	offset += readLen
 
-	slog.Debug("Unpacked Bitmap fields", "fields", presentFields)
-
-	// 3. Extract Fields based on the map returned by the encoder
-	// We loop from field 2 up to 128
-	for i := 2; i <= 128; i++ {
-		// Only unpack if the bitmap says the field is present
-		if presentFields[i] {
-			fSpec, defined := spec.Fields[i]
-			if !defined {
-				return fmt.Errorf("field %d found in bitmap but not in spec", i)
-			}
-
-			// Unpack the data field
-			val, readLen, err := fSpec.Encoder.Unpack(data[offset:], fSpec.Length)
-			if err != nil {
-				return fmt.Errorf("error unpacking field %d: %v", i, err)
-			}
-
-			slog.Debug("Unpacked Field", "field", i, "value", val)
-
-			// Store the value in the message
-			m.Fields[i] = &Field{Value: []byte(val)}
-			offset += readLen
-		}
+	// 2. Unpack Bitmap and Fields
+	bitmap, _, err := unpackBitmapped(data[offset:], offset, "", spec, &m.Fields, &m.arena)
+	if err != nil {
+		return err
+	}
+	// Store raw bytes for debugging, and to repack them unchanged
+	m.Bitmap = bitmap
+	m.spec = spec
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		slog.Debug("Unpacked fields", "fields", m.LogStringWith(top))
 	}
 
 	return nil
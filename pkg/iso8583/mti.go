@@ -0,0 +1,180 @@
+package iso8583
+
+import (
+	"fmt"
+)
+
+// MTIVersion is the first MTI digit, the ISO 8583 version
+type MTIVersion byte
+
+const (
+	Version1987     MTIVersion = '0'
+	Version1993     MTIVersion = '1'
+	Version2003     MTIVersion = '2'
+	VersionNational MTIVersion = '8'
+	VersionPrivate  MTIVersion = '9'
+)
+
+// Year returns 1987, 1993 or 2003, or 0 for national and private use
+func (v MTIVersion) Year() int {
+	switch v {
+	case Version1987:
+		return 1987
+	case Version1993:
+		return 1993
+	case Version2003:
+		return 2003
+	}
+	return 0
+}
+
+// MTIClass is the second MTI digit, the overall purpose of the message
+type MTIClass byte
+
+const (
+	ClassAuthorization     MTIClass = '1'
+	ClassFinancial         MTIClass = '2'
+	ClassFileAction        MTIClass = '3'
+	ClassReversal          MTIClass = '4' // Reversals, and chargebacks since 1993
+	ClassReconciliation    MTIClass = '5'
+	ClassAdministrative    MTIClass = '6'
+	ClassFeeCollection     MTIClass = '7'
+	ClassNetworkManagement MTIClass = '8'
+)
+
+// MTIFunction is the third MTI digit, the role of the message in the exchange
+type MTIFunction byte
+
+const (
+	FunctionRequest          MTIFunction = '0'
+	FunctionRequestResponse  MTIFunction = '1'
+	FunctionAdvice           MTIFunction = '2'
+	FunctionAdviceResponse   MTIFunction = '3'
+	FunctionNotification     MTIFunction = '4'
+	FunctionNotificationAck  MTIFunction = '5'
+	FunctionInstruction      MTIFunction = '6'
+	FunctionInstructionAck   MTIFunction = '7'
+	FunctionResponseAck      MTIFunction = '8'
+	FunctionNegativeResponse MTIFunction = '9'
+)
+
+// responseFunctions maps the functions that expect an answer to that answer
+var responseFunctions = map[MTIFunction]MTIFunction{
+	FunctionRequest:      FunctionRequestResponse,
+	FunctionAdvice:       FunctionAdviceResponse,
+	FunctionNotification: FunctionNotificationAck,
+	FunctionInstruction:  FunctionInstructionAck,
+}
+
+// MTIOrigin is the fourth MTI digit, who sent the message; odd values are repeats
+type MTIOrigin byte
+
+const (
+	OriginAcquirer       MTIOrigin = '0'
+	OriginAcquirerRepeat MTIOrigin = '1'
+	OriginIssuer         MTIOrigin = '2'
+	OriginIssuerRepeat   MTIOrigin = '3'
+	OriginOther          MTIOrigin = '4'
+	OriginOtherRepeat    MTIOrigin = '5'
+)
+
+// MTI is a parsed message type indicator, e.g., 0200 is a 1987 financial
+// request from the acquirer
+type MTI struct {
+	Version  MTIVersion
+	Class    MTIClass
+	Function MTIFunction
+	Origin   MTIOrigin
+}
+
+// ParseMTI splits a 4-digit MTI into its components
+func ParseMTI(s string) (MTI, error) {
+	if len(s) != 4 {
+		return MTI{}, fmt.Errorf("MTI must be 4 characters long")
+	}
+	for i := 0; i < 4; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return MTI{}, fmt.Errorf("invalid MTI %q: non-digit character", s)
+		}
+	}
+	return MTI{
+		Version:  MTIVersion(s[0]),
+		Class:    MTIClass(s[1]),
+		Function: MTIFunction(s[2]),
+		Origin:   MTIOrigin(s[3]),
+	}, nil
+}
+
+func (m MTI) String() string {
+	return string([]byte{byte(m.Version), byte(m.Class), byte(m.Function), byte(m.Origin)})
+}
+
+// IsRequest reports whether the message is a request (e.g., 0100, 0200, 0800)
+func (m MTI) IsRequest() bool {
+	return m.Function == FunctionRequest
+}
+
+// IsResponse reports whether the message answers another one (e.g., 0110, 0230, 0410)
+func (m MTI) IsResponse() bool {
+	for _, resp := range responseFunctions {
+		if m.Function == resp {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdvice reports whether the message is an advice or an advice response (e.g., 0120, 0130)
+func (m MTI) IsAdvice() bool {
+	return m.Function == FunctionAdvice || m.Function == FunctionAdviceResponse
+}
+
+// IsRepeat reports whether the message is a repeat of an earlier one (e.g., 0201, 0421)
+func (m MTI) IsRepeat() bool {
+	switch m.Origin {
+	case OriginAcquirerRepeat, OriginIssuerRepeat, OriginOtherRepeat:
+		return true
+	}
+	return false
+}
+
+// IsReversal reports whether the message belongs to the reversal class (e.g., 0400, 0420)
+func (m MTI) IsReversal() bool {
+	return m.Class == ClassReversal
+}
+
+// IsNetworkManagement reports whether the message is a network management message (e.g., 0800)
+func (m MTI) IsNetworkManagement() bool {
+	return m.Class == ClassNetworkManagement
+}
+
+// Response returns the MTI that answers m. The function moves to its response
+// (request -> response, advice -> advice response, notification and
+// instruction -> acknowledgement) and a repeat is answered like the original,
+// e.g., 0201 -> 0210. Messages that expect no answer are an error.
+func (m MTI) Response() (MTI, error) {
+	fn, ok := responseFunctions[m.Function]
+	if !ok {
+		return MTI{}, fmt.Errorf("cannot convert MTI %s to response", m)
+	}
+
+	resp := m
+	resp.Function = fn
+	if m.IsRepeat() {
+		resp.Origin--
+	}
+	return resp, nil
+}
+
+// ResponseMTI maps a request MTI string to its response, e.g., "0200" -> "0210"
+func ResponseMTI(mti string) (string, error) {
+	parsed, err := ParseMTI(mti)
+	if err != nil {
+		return "", err
+	}
+	resp, err := parsed.Response()
+	if err != nil {
+		return "", err
+	}
+	return resp.String(), nil
+}
@@ -0,0 +1,60 @@
+package iso8583
+
+import "testing"
+
+func TestParseMTI(t *testing.T) {
+	m, err := ParseMTI("1421")
+	if err != nil {
+		t.Fatalf("ParseMTI failed: %v", err)
+	}
+	if m.Version != Version1993 || m.Version.Year() != 1993 || m.Class != ClassReversal ||
+		m.Function != FunctionAdvice || m.Origin != OriginAcquirerRepeat {
+		t.Errorf("unexpected components: %+v", m)
+	}
+	if !m.IsReversal() || !m.IsAdvice() || !m.IsRepeat() || m.IsRequest() || m.IsResponse() {
+		t.Errorf("unexpected predicates for %s", m)
+	}
+	if m.String() != "1421" {
+		t.Errorf("String: got %s", m)
+	}
+
+	for _, bad := range []string{"020", "02000", "02A0"} {
+		if _, err := ParseMTI(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestResponseMTI(t *testing.T) {
+	tests := map[string]string{
+		"0100": "0110",
+		"0200": "0210",
+		"0201": "0210", // A repeat is answered like the original
+		"0220": "0230",
+		"0400": "0410",
+		"0421": "0430",
+		"0800": "0810",
+		"1804": "1814",
+		"1644": "1654", // Notification -> acknowledgement
+		"2602": "2612",
+		"1363": "1372", // Instruction repeat from the issuer
+	}
+	for req, want := range tests {
+		got, err := ResponseMTI(req)
+		if err != nil || got != want {
+			t.Errorf("%s: got %s, %v; want %s", req, got, err, want)
+		}
+	}
+
+	for _, mti := range []string{"0210", "0130", "1418", "0290"} {
+		if _, err := ResponseMTI(mti); err == nil {
+			t.Errorf("expected error for %s", mti)
+		}
+	}
+
+	msg := NewMessage()
+	msg.MTI = "0201"
+	if err := msg.ResponseMTI(); err != nil || msg.MTI != "0210" {
+		t.Errorf("Message.ResponseMTI: got %s, %v", msg.MTI, err)
+	}
+}
@@ -0,0 +1,105 @@
+package iso8583
+
+import "sync"
+
+var messagePool = sync.Pool{
+	New: func() any { return NewMessage() },
+}
+
+// AcquireMessage returns an empty message from a pool. Unpacking into a
+// pooled message reuses the memory of its previous use, so a busy switch
+// allocates little more than the decoded values. Hand it back with
+// ReleaseMessage once done.
+func AcquireMessage() *Message {
+	return messagePool.Get().(*Message)
+}
+
+// ReleaseMessage resets m and returns it to the pool. Neither m nor anything
+// taken from it (fields, Value or Raw slices) may be used afterwards; Clone
+// what has to outlive it.
+func ReleaseMessage(m *Message) {
+	m.Reset()
+	messagePool.Put(m)
+}
+
+// Reset empties the message, keeping its memory for the next Unpack
+func (m *Message) Reset() {
+	m.Fields.Reset()
+	m.Header = nil
+	m.MTI = ""
+	m.Bitmap = nil
+	m.spec = nil
+	m.arena.reset()
+}
+
+// arena hands out the fields and bytes of unpacked messages from a few
+// large allocations. A nil arena allocates each of them on its own.
+type arena struct {
+	fields []Field
+	tables []Fields // Subfields of composites
+	buf    []byte
+}
+
+// field returns a zeroed Field
+func (a *arena) field() *Field {
+	if a == nil {
+		return &Field{}
+	}
+	if len(a.fields) == cap(a.fields) {
+		// Fields handed out so far stay in the previous block
+		a.fields = make([]Field, 0, max(16, 2*cap(a.fields)))
+	}
+	a.fields = a.fields[:len(a.fields)+1]
+	return &a.fields[len(a.fields)-1]
+}
+
+// fieldTable returns an empty Fields
+func (a *arena) fieldTable() *Fields {
+	if a == nil {
+		return &Fields{}
+	}
+	if len(a.tables) == cap(a.tables) {
+		a.tables = make([]Fields, 0, max(4, 2*cap(a.tables)))
+	}
+	a.tables = a.tables[:len(a.tables)+1]
+	return &a.tables[len(a.tables)-1]
+}
+
+// copy returns a copy of data; its capacity is capped so that appending to
+// it cannot overwrite what follows
+func (a *arena) copy(data []byte) []byte {
+	if a == nil {
+		return append([]byte(nil), data...)
+	}
+	b := a.reserve(len(data))
+	return append(b, data...)[:len(data):len(data)]
+}
+
+// bytes is copy for strings
+func (a *arena) bytes(s string) []byte {
+	if a == nil {
+		return []byte(s)
+	}
+	b := a.reserve(len(s))
+	return append(b, s...)[:len(s):len(s)]
+}
+
+// reserve makes room for n bytes and returns an empty slice where they go
+func (a *arena) reserve(n int) []byte {
+	if cap(a.buf)-len(a.buf) < n {
+		a.buf = make([]byte, 0, max(n, 2*cap(a.buf), 1024))
+	}
+	start := len(a.buf)
+	a.buf = a.buf[:start+n]
+	return a.buf[start : start : start+n]
+}
+
+func (a *arena) reset() {
+	clear(a.fields)
+	a.fields = a.fields[:0]
+	for i := range a.tables {
+		a.tables[i].Reset()
+	}
+	a.tables = a.tables[:0]
+	a.buf = a.buf[:0]
+}
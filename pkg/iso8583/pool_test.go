@@ -0,0 +1,59 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"testing"
+)
+
+func TestPooledMessageReuse(t *testing.T) {
+	spec := &Spec{
+		MTIEncoder:    &field.FANumeric{},
+		BitmapEncoder: &field.FBBitmap{},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			2:  {Length: 19, Encoder: &field.FALLNumeric{}},
+			3:  {Length: 6, Encoder: &field.FANumeric{}},
+			11: {Length: 6, Encoder: &field.FANumeric{}},
+		}),
+	}
+	first := NewMessage()
+	first.MTI = "0200"
+	first.Set(2, "4111111111111111")
+	first.Set(3, "000000")
+	firstData, _ := first.Pack(spec)
+
+	second := NewMessage()
+	second.MTI = "0800"
+	second.Set(11, "000042")
+	secondData, _ := second.Pack(spec)
+
+	msg := AcquireMessage()
+	if err := msg.Unpack(firstData, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	kept := msg.Fields.Get(2).Clone()
+
+	// Appending to a value must not spill into the next field
+	msg.Fields.Get(2).Value = append(msg.Fields.Get(2).Value, '9')
+	if msg.Get(3) != "000000" {
+		t.Errorf("field 3 overwritten: %q", msg.Get(3))
+	}
+	ReleaseMessage(msg)
+
+	msg = AcquireMessage()
+	defer ReleaseMessage(msg)
+	if err := msg.Unpack(secondData, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if msg.MTI != "0800" || msg.Fields.Len() != 1 || msg.Get(11) != "000042" {
+		t.Errorf("stale state after release: %s", msg.LogString())
+	}
+	if string(kept.Value) != "4111111111111111" {
+		t.Errorf("clone changed after release: %q", kept.Value)
+	}
+
+	packed, err := msg.AppendPack([]byte("HDR"), spec)
+	if err != nil || !bytes.Equal(packed, append([]byte("HDR"), secondData...)) {
+		t.Errorf("AppendPack = % X, %v", packed, err)
+	}
+}
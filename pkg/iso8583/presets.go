@@ -0,0 +1,119 @@
+package iso8583
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed presets/*.yaml
+var presetFS embed.FS
+
+// Built-in spec presets
+const (
+	PresetISO87ASCII = "iso87ascii"
+	PresetISO93ASCII = "iso93ascii"
+	// PresetISO2003ASCII is an alias of PresetISO93ASCII, on purpose: the
+	// data elements it defines kept their 1993 layout in the 2003 edition.
+	// Hosts using 2003 additions need a spec of their own.
+	PresetISO2003ASCII = "iso2003ascii"
+)
+
+// presetAliases names the presets built from the file of another
+var presetAliases = map[string]string{
+	PresetISO2003ASCII: PresetISO93ASCII,
+}
+
+// LoadPreset builds one of the embedded specs, e.g., LoadPreset(PresetISO93ASCII)
+func LoadPreset(name string) (*Spec, error) {
+	file := name
+	if target, ok := presetAliases[name]; ok {
+		file = target
+	}
+	data, err := presetFS.ReadFile("presets/" + file + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown spec preset: %s", name)
+	}
+	spec, err := LoadSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	return spec, nil
+}
+
+// PresetNames lists the embedded presets, aliases included
+func PresetNames() []string {
+	entries, _ := presetFS.ReadDir("presets")
+	names := make([]string, 0, len(entries)+len(presetAliases))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	for alias := range presetAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadASCIIPresets returns a spec that picks the 1987, 1993 or 2003 ASCII
+// preset for each message from the version digit of its MTI
+func LoadASCIIPresets() (*Spec, error) {
+	versions := make(map[MTIVersion]*Spec)
+	for v, name := range map[MTIVersion]string{
+		Version1987: PresetISO87ASCII,
+		Version1993: PresetISO93ASCII,
+		Version2003: PresetISO2003ASCII,
+	} {
+		spec, err := LoadPreset(name)
+		if err != nil {
+			return nil, err
+		}
+		versions[v] = spec
+	}
+	return NewVersionedSpec(versions)
+}
+
+// NewVersionedSpec combines per-version specs into one, selected by the MTI
+// version digit of each message. They must agree on the MTI encoder, since the
+// MTI is read before the version is known.
+func NewVersionedSpec(versions map[MTIVersion]*Spec) (*Spec, error) {
+	spec := &Spec{Versions: versions}
+	for _, v := range sortedVersions(versions) {
+		vs := versions[v]
+		if spec.MTIEncoder == nil {
+			spec.MTIEncoder = vs.MTIEncoder
+		} else if encoderName(vs.MTIEncoder) != encoderName(spec.MTIEncoder) {
+			return nil, fmt.Errorf("version %c: MTI encoder %s differs from %s",
+				v, encoderName(vs.MTIEncoder), encoderName(spec.MTIEncoder))
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// forMTI resolves the spec that lays out the bitmap and fields of a message
+func (s *Spec) forMTI(mti string) (*Spec, error) {
+	if len(s.Versions) == 0 {
+		return s, nil
+	}
+	if mti == "" {
+		return nil, fmt.Errorf("MTI is required to select the spec version")
+	}
+	vs, ok := s.Versions[MTIVersion(mti[0])]
+	if !ok {
+		return nil, fmt.Errorf("no spec for MTI version %c", mti[0])
+	}
+	return vs, nil
+}
+
+func sortedVersions(versions map[MTIVersion]*Spec) []MTIVersion {
+	keys := make([]MTIVersion, 0, len(versions))
+	for v := range versions {
+		keys = append(keys, v)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
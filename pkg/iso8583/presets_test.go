@@ -0,0 +1,132 @@
+package iso8583
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadPresets(t *testing.T) {
+	names := PresetNames()
+	if len(names) != 3 {
+		t.Fatalf("expected 3 presets, got %v", names)
+	}
+	for _, name := range names {
+		if _, err := LoadPreset(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := LoadPreset("iso2525ascii"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+
+	iso93, _ := LoadPreset(PresetISO93ASCII)
+	if f := iso93.Fields.Get(24); f.Description != "Function Code" || f.Length != 3 {
+		t.Errorf("1993 field 24: got %+v", f)
+	}
+	if f := iso93.Fields.Get(39); f.Length != 3 {
+		t.Errorf("1993 field 39 should be a 3-digit action code, got %+v", f)
+	}
+
+	// The 2003 preset is an alias of the 1993 one
+	iso2003, err := LoadPreset(PresetISO2003ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(iso2003.Fields.Keys(), iso93.Fields.Keys()) {
+		t.Errorf("2003 fields %v, want the 1993 ones", iso2003.Fields.Keys())
+	}
+	for id, f := range iso93.Fields.All() {
+		if g := iso2003.Fields.Get(id); g.Description != f.Description || g.Length != f.Length {
+			t.Errorf("2003 field %d: got %+v, want %+v", id, g, f)
+		}
+	}
+}
+
+func TestVersionedSpec(t *testing.T) {
+	spec, err := LoadASCIIPresets()
+	if err != nil {
+		t.Fatalf("LoadASCIIPresets failed: %v", err)
+	}
+
+	// Field 12 is hhmmss in 1987 and YYMMDDhhmmss from 1993 on
+	tests := []struct {
+		mti      string
+		field12  string
+		wantBody string
+	}{
+		{"0200", "143005", "143005"},
+		{"1200", "261018143005", "261018143005"},
+		{"2200", "261018143005", "261018143005"},
+	}
+	for _, tt := range tests {
+		msg := NewMessage()
+		msg.MTI = tt.mti
+		msg.Set(11, "000001")
+		msg.Set(12, tt.field12)
+
+		packed, err := msg.Pack(spec)
+		if err != nil {
+			t.Fatalf("%s: Pack failed: %v", tt.mti, err)
+		}
+		if !strings.HasSuffix(string(packed), "000001"+tt.wantBody) {
+			t.Errorf("%s: unexpected payload %q", tt.mti, packed)
+		}
+
+		got := NewMessage()
+		if err := got.Unpack(packed, spec); err != nil {
+			t.Fatalf("%s: Unpack failed: %v", tt.mti, err)
+		}
+		if got.Get(12) != tt.field12 {
+			t.Errorf("%s: field 12 got %q", tt.mti, got.Get(12))
+		}
+	}
+
+	if spec.Versions[Version2003] == nil {
+		t.Error("2003 messages have no preset")
+	}
+
+	msg := NewMessage()
+	msg.MTI = "9200"
+	if _, err := msg.Pack(spec); err == nil {
+		t.Error("expected error for a version without spec")
+	}
+	if err := NewMessage().Unpack([]byte("9200"+"0000000000000000"), spec); err == nil {
+		t.Error("expected error unpacking a version without spec")
+	}
+}
+
+func TestResponseCodes(t *testing.T) {
+	tests := []struct {
+		mti, rc, want string
+	}{
+		{"0210", "00", "00"},
+		{"0210", "51", "51"},
+		{"1210", "00", "000"},
+		{"1210", "51", "116"},
+		{"1430", "00", "400"},
+		{"1814", "00", "800"},
+		{"2210", "05", "100"},
+		{"1210", "121", "121"}, // Action codes pass through
+	}
+	for _, tt := range tests {
+		msg := NewMessage()
+		msg.MTI = tt.mti
+		if err := msg.SetResponseCode(tt.rc); err != nil {
+			t.Errorf("%s %s: %v", tt.mti, tt.rc, err)
+			continue
+		}
+		if msg.Get(39) != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.mti, tt.rc, msg.Get(39), tt.want)
+		}
+		if len(tt.rc) == 2 && msg.ResponseCode() != tt.rc {
+			t.Errorf("%s %s: ResponseCode got %s", tt.mti, tt.rc, msg.ResponseCode())
+		}
+	}
+
+	msg := NewMessage()
+	msg.MTI = "1210"
+	if err := msg.SetResponseCode("Z9"); err == nil {
+		t.Error("expected error for a response code without action code")
+	}
+}
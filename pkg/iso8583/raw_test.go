@@ -0,0 +1,111 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"testing"
+)
+
+func TestRepackUntouchedFieldsByteExact(t *testing.T) {
+	spec := &Spec{
+		MTIEncoder:    &field.FBNumeric{},
+		BitmapEncoder: &field.FBBitmap{},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			2: {Length: 19, Encoder: &field.FBLLNumeric{}},
+			3: {Length: 6, Encoder: &field.FBNumeric{}},
+		}),
+	}
+
+	// Odd-length PAN padded with an F nibble, which we would re-encode as 0
+	data := []byte{
+		0x02, 0x00,
+		0x60, 0, 0, 0, 0, 0, 0, 0,
+		0x03, 0xF1, 0x23,
+		0x00, 0x00, 0x00,
+	}
+
+	msg := NewMessage()
+	if err := msg.Unpack(data, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if msg.Get(2) != "123" || !bytes.Equal(msg.Fields.Get(2).Raw, data[10:13]) {
+		t.Fatalf("field 2: value %q raw % X", msg.Get(2), msg.Fields.Get(2).Raw)
+	}
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if !bytes.Equal(packed, data) {
+		t.Errorf("untouched message not byte-exact:\n got % X\nwant % X", packed, data)
+	}
+
+	// Changing another field keeps field 2 raw
+	msg.Set(3, "010000")
+	packed, _ = msg.Pack(spec)
+	if !bytes.Equal(packed[10:13], data[10:13]) || !bytes.Equal(packed[13:], []byte{0x01, 0, 0}) {
+		t.Errorf("unexpected repack: % X", packed)
+	}
+
+	// Editing the value in place re-encodes it
+	msg.Fields.Get(2).Value = []byte("124")
+	packed, _ = msg.Pack(spec)
+	if !bytes.Equal(packed[10:13], []byte{0x03, 0x01, 0x24}) {
+		t.Errorf("edited field not re-encoded: % X", packed[10:13])
+	}
+
+	// Unpacking with one spec and packing with another re-encodes
+	msg = NewMessage()
+	msg.Unpack(data, spec)
+	other := *spec
+	other.Fields = *NewFieldTable(map[int]FieldSpec{
+		2: {Length: 19, Encoder: &field.FBLLNumeric{}},
+		3: spec.Fields.Get(3),
+	})
+	packed, _ = msg.Pack(&other)
+	if !bytes.Equal(packed[10:13], []byte{0x03, 0x01, 0x23}) {
+		t.Errorf("field 2 should be re-encoded for another spec: % X", packed[10:13])
+	}
+}
+
+func TestRepackRawBitmapAndComposite(t *testing.T) {
+	spec := testSpec()
+	spec.Fields.Set(5, FieldSpec{Length: 2, Encoder: &field.FANumeric{}})
+	spec.Fields.Set(7, FieldSpec{Length: 2, Encoder: &field.FANumeric{}})
+
+	// Lower-case hex bitmap announcing fields 5 and 7
+	data := []byte("0200" + "0a00000000000000" + "12" + "34")
+	msg := NewMessage()
+	if err := msg.Unpack(data, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	packed, _ := msg.Pack(spec)
+	if !bytes.Equal(packed, data) {
+		t.Errorf("got %q, want %q", packed, data)
+	}
+
+	msg.Set(11, "000001")
+	packed, _ = msg.Pack(spec)
+	if string(packed[4:20]) != "0A20000000000000" {
+		t.Errorf("bitmap should be rebuilt, got %q", packed[4:20])
+	}
+
+	// Composite subfields edited through SetPath rebuild their parent
+	src := NewMessage()
+	src.MTI = "0200"
+	src.SetPath("127.2", "KEY")
+	wire, _ := src.Pack(spec)
+
+	msg = NewMessage()
+	msg.Unpack(wire, spec)
+	msg.Fields.Get(127).Raw[0] = 'X' // would show up if the stale raw bytes were reused
+	msg.SetPath("127.22", "DATA")
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	check := NewMessage()
+	if err := check.Unpack(packed, spec); err != nil || check.GetPath("127.22") != "DATA" || check.GetPath("127.2") != "KEY" {
+		t.Errorf("composite not rebuilt: %v %s", err, packed)
+	}
+}
@@ -0,0 +1,44 @@
+package iso8583
+
+import "bytes"
+
+// DefaultEchoFields are the request fields a response carries back unchanged:
+// PAN, processing code, amount, transmission date/time, STAN, local time and
+// date, acquirer ID, RRN, terminal ID, merchant ID and currency code
+var DefaultEchoFields = []int{2, 3, 4, 7, 11, 12, 13, 32, 37, 41, 42, 49}
+
+// ResponseBuilder derives a response from a request without touching the request
+type ResponseBuilder struct {
+	EchoFields []int
+}
+
+// NewResponseBuilder copies the given fields, or DefaultEchoFields when none are given
+func NewResponseBuilder(echoFields ...int) *ResponseBuilder {
+	if len(echoFields) == 0 {
+		echoFields = DefaultEchoFields
+	}
+	return &ResponseBuilder{EchoFields: echoFields}
+}
+
+// Build returns a new message with the response MTI, the request header and
+// copies of the echo fields present in the request
+func (b *ResponseBuilder) Build(req *Message) (*Message, error) {
+	resp := NewMessage()
+	resp.MTI = req.MTI
+	if err := resp.ResponseMTI(); err != nil {
+		return nil, err
+	}
+	resp.Header = bytes.Clone(req.Header)
+
+	for _, id := range b.EchoFields {
+		if f, ok := req.Fields.Lookup(id); ok {
+			resp.Fields.Set(id, f.Clone())
+		}
+	}
+	return resp, nil
+}
+
+// NewResponse builds a response to req echoing DefaultEchoFields
+func NewResponse(req *Message) (*Message, error) {
+	return NewResponseBuilder().Build(req)
+}
@@ -0,0 +1,47 @@
+package iso8583
+
+import "testing"
+
+func TestResponseBuilder(t *testing.T) {
+	req := NewMessage()
+	req.MTI = "0200"
+	req.SetHeader([]byte{0x60, 0x00, 0x01, 0x00, 0x00})
+	for id, val := range map[int]string{2: "4111111111111111", 4: "1000", 11: "000123", 22: "051", 41: "TERM01"} {
+		req.Set(id, val)
+	}
+
+	resp, err := NewResponse(req)
+	if err != nil {
+		t.Fatalf("NewResponse failed: %v", err)
+	}
+	if resp.MTI != "0210" || req.MTI != "0200" {
+		t.Errorf("MTI: response %s, request %s", resp.MTI, req.MTI)
+	}
+	for _, id := range []int{2, 4, 11, 41} {
+		if resp.Get(id) != req.Get(id) {
+			t.Errorf("field %d not echoed", id)
+		}
+	}
+	if resp.Fields.Has(22) {
+		t.Error("field 22 is not an echo field")
+	}
+	if string(resp.Header) != string(req.Header) {
+		t.Errorf("header: got % X", resp.Header)
+	}
+
+	resp.Set(39, "00")
+	resp.Fields.Get(11).Value[0] = '9'
+	if req.Fields.Has(39) || req.Get(11) != "000123" {
+		t.Error("building the response changed the request")
+	}
+
+	resp, _ = NewResponseBuilder(11).Build(req)
+	if resp.Fields.Len() != 1 || resp.Get(11) != "000123" {
+		t.Errorf("custom echo fields: got %s", resp.LogString())
+	}
+
+	req.MTI = "0210"
+	if _, err := NewResponse(req); err == nil {
+		t.Error("expected error for a response MTI")
+	}
+}
@@ -2,18 +2,58 @@ package iso8583
 
 import (
 	"GoSwitch/pkg/field"
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
 )
 
+// YAMLEncoder names a registered encoder for the MTI
+type YAMLEncoder struct {
+	Encoder string `yaml:"encoder"`
+}
+
+// YAMLBitmap names a registered bitmap encoder; tertiary enables fields 129-192
+type YAMLBitmap struct {
+	Encoder  string `yaml:"encoder"`
+	Tertiary bool   `yaml:"tertiary"`
+}
+
+// YAMLPrefix describes the length header of a generic variable-length field
+type YAMLPrefix struct {
+	Type   string `yaml:"type"`   // ascii, bcd, ebcdic or binary
+	Digits int    `yaml:"digits"` // ascii, bcd and ebcdic
+	Bytes  int    `yaml:"bytes"`  // binary
+}
+
 // YAMLSpec matches the structure of your .yaml file
 type YAMLSpec struct {
-	Fields map[int]struct {
-		Length      int    `yaml:"length"`
-		Description string `yaml:"description"`
-		Encoder     string `yaml:"encoder"` // e.g., "FANumeric", "FBNumeric"
-	} `yaml:"fields"`
+	MTI    YAMLEncoder `yaml:"mti"`    // e.g., "FANumeric" (ASCII), "FBNumeric" (BCD)
+	Bitmap YAMLBitmap  `yaml:"bitmap"` // e.g., "FBBitmap" (binary), "FABitmap" (hex ASCII)
+	Strict bool        `yaml:"strict"` // Default strict mode for every field
+	// LogClear disables masking of sensitive fields in logs; test environments only
+	LogClear bool              `yaml:"log_clear"`
+	Fields   map[int]YAMLField `yaml:"fields"`
+}
+
+// YAMLField describes one field; composites nest their own subfields
+type YAMLField struct {
+	Length      int    `yaml:"length"`
+	Description string `yaml:"description"`
+	Encoder     string `yaml:"encoder"` // Registered name, e.g., "FANumeric", "FBLLNumeric"
+	Class       string `yaml:"class"`   // Content class: n, a, an, ans, b, z or x+n
+	Strict      *bool  `yaml:"strict"`  // Overrides the spec-wide strict mode
+	Mask        string `yaml:"mask"`    // Log masking: pan, redact, hash or none
+
+	// Generic variable-length layout, used instead of a named encoder
+	Prefix  *YAMLPrefix `yaml:"prefix"`
+	Content string      `yaml:"content"` // ascii, ebcdic, bcd, binary or tlv
+
+	// Composite only: a sub-bitmap (e.g., field 127), or a tag width for
+	// tag-length-value layouts; with neither, subfields are fixed-position
+	Bitmap    YAMLBitmap        `yaml:"bitmap"`
+	TagLength int               `yaml:"tag_length"`
+	Subfields map[int]YAMLField `yaml:"subfields"`
 }
 
 // FieldSpec defines how a specific field should be packed/unpacked
@@ -21,13 +61,37 @@ type FieldSpec struct {
 	Length      int
 	Description string
 	Encoder     field.ISOField
+	// Class restricts the characters of the value; checked in Strict mode only
+	Class field.Class
+	// Strict turns truncation, illegal characters and short fixed-length
+	// values into errors on pack and unpack
+	Strict bool
+	// Mask hides the value in logs and dumps; empty keeps the default
+	// (PAN truncated, tracks, PIN block and ICC data redacted)
+	Mask Mask
+	// Subfields turns the field into a composite. The Encoder handles the
+	// outer length prefix, the nested spec lays out the content.
+	Subfields *Spec
 }
 
 // Spec defines the configuration for the ISO8583 message
 type Spec struct {
 	MTIEncoder    field.ISOField
 	BitmapEncoder field.BitMap
-	Fields        map[int]FieldSpec
+	Fields        FieldSpecs
+
+	// TagLength applies to composite specs only: every subfield is preceded
+	// by its number as TagLength ASCII digits (e.g., field 48 "01" + LL + data)
+	TagLength int
+
+	// Versions, when set, holds one spec per MTI version digit; the MTI is
+	// read with MTIEncoder and selects the spec for the rest of the message.
+	// See NewVersionedSpec.
+	Versions map[MTIVersion]*Spec
+
+	// LogClear turns off masking in LogString, Dump and engine logs. Meant
+	// for test environments; never set it where real card data flows.
+	LogClear bool
 }
 
 // LoadSpecFromFile reads a YAML file and returns a usable Spec
@@ -36,37 +100,132 @@ func LoadSpecFromFile(path string) (*Spec, error) {
 	if err != nil {
 		return nil, err
 	}
+	return LoadSpec(data)
+}
 
+// LoadSpec builds a Spec from YAML content
+func LoadSpec(data []byte) (*Spec, error) {
 	var y YAMLSpec
 	if err := yaml.Unmarshal(data, &y); err != nil {
 		return nil, err
 	}
 
+	// Sections left out keep the historical ASCII MTI and binary bitmap
+	mtiName := y.MTI.Encoder
+	if mtiName == "" {
+		mtiName = "FANumeric"
+	}
+	mtiEncoder, err := field.New(mtiName)
+	if err != nil {
+		return nil, fmt.Errorf("mti: %w", err)
+	}
+
+	bitmapName := y.Bitmap.Encoder
+	if bitmapName == "" {
+		bitmapName = "FBBitmap"
+	}
+	bitmapEncoder, err := field.NewBitmap(bitmapName, y.Bitmap.Tertiary)
+	if err != nil {
+		return nil, fmt.Errorf("bitmap: %w", err)
+	}
+
 	spec := &Spec{
-		Fields:        make(map[int]FieldSpec),
-		MTIEncoder:    &field.FANumeric{},
-		BitmapEncoder: &field.FBBitmap{},
-	}
-
-	for id, f := range y.Fields {
-		var encoder field.ISOField
-
-		switch f.Encoder {
-		case "FBNumeric":
-			encoder = &field.FBNumeric{}
-		case "FANumeric":
-			encoder = &field.FANumeric{}
-		default:
-			// Fallback to ASCII Numeric
-			encoder = &field.FANumeric{}
+		MTIEncoder:    mtiEncoder,
+		BitmapEncoder: bitmapEncoder,
+		LogClear:      y.LogClear,
+	}
+
+	if err := buildFields(&spec.Fields, y.Fields, "", y.Strict); err != nil {
+		return nil, err
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// buildFields resolves encoders for a (sub)field map into dst; prefix carries
+// the parent path so errors read like "field 127.22: ..."
+func buildFields(dst *FieldSpecs, fields map[int]YAMLField, prefix string, strict bool) error {
+	for id, f := range fields {
+		path := fmt.Sprintf("%s%d", prefix, id)
+
+		encoder, err := buildEncoder(f)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
+		}
+
+		class, err := field.ParseClass(f.Class)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
+		}
+
+		mask, err := ParseMask(f.Mask)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", path, err)
 		}
 
-		spec.Fields[id] = FieldSpec{
+		fSpec := FieldSpec{
 			Length:      f.Length,
 			Description: f.Description,
 			Encoder:     encoder,
+			Class:       class,
+			Strict:      strict,
+			Mask:        mask,
+		}
+		if f.Strict != nil {
+			fSpec.Strict = *f.Strict
+		}
+
+		if len(f.Subfields) > 0 {
+			sub := &Spec{TagLength: f.TagLength}
+			if f.Bitmap.Encoder != "" {
+				sub.BitmapEncoder, err = field.NewBitmap(f.Bitmap.Encoder, f.Bitmap.Tertiary)
+				if err != nil {
+					return fmt.Errorf("field %s bitmap: %w", path, err)
+				}
+			}
+			if err := buildFields(&sub.Fields, f.Subfields, path+".", strict); err != nil {
+				return err
+			}
+			fSpec.Subfields = sub
 		}
+
+		dst.Set(id, fSpec)
 	}
 
-	return spec, nil
+	return nil
+}
+
+// buildEncoder resolves a named encoder, or assembles a generic variable-length one
+func buildEncoder(f YAMLField) (field.ISOField, error) {
+	if f.Prefix == nil {
+		// Unknown encoder names are a hard error; a silent fallback
+		// would pack the field with the wrong representation
+		return field.New(f.Encoder)
+	}
+	if f.Encoder != "" {
+		return nil, fmt.Errorf("encoder %s cannot be combined with prefix", f.Encoder)
+	}
+
+	size := f.Prefix.Digits
+	if f.Prefix.Type == "binary" {
+		size = f.Prefix.Bytes
+	}
+	prefix, err := field.NewLengthPrefix(f.Prefix.Type, size)
+	if err != nil {
+		return nil, err
+	}
+
+	contentName := f.Content
+	if contentName == "" {
+		contentName = "ascii"
+	}
+	content, err := field.NewContent(contentName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &field.Variable{Prefix: prefix, Content: content}, nil
 }
@@ -0,0 +1,223 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSpec(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	return path
+}
+
+func TestLoadSpecFromFileEncoders(t *testing.T) {
+	path := writeSpec(t, `
+fields:
+  2:
+    length: 19
+    encoder: "FALLNumeric"
+  35:
+    length: 37
+    encoder: "FBLLNumeric"
+  41:
+    length: 8
+    encoder: "FChar"
+  55:
+    length: 255
+    encoder: "FBLLLChar"
+`)
+	spec, err := LoadSpecFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+
+	if _, ok := spec.Fields.Get(2).Encoder.(*field.FALLNumeric); !ok {
+		t.Errorf("field 2: got %T", spec.Fields.Get(2).Encoder)
+	}
+	if _, ok := spec.Fields.Get(35).Encoder.(*field.FBLLNumeric); !ok {
+		t.Errorf("field 35: got %T", spec.Fields.Get(35).Encoder)
+	}
+	if _, ok := spec.Fields.Get(41).Encoder.(*field.FChar); !ok {
+		t.Errorf("field 41: got %T", spec.Fields.Get(41).Encoder)
+	}
+	if _, ok := spec.Fields.Get(55).Encoder.(*field.FBLLLChar); !ok {
+		t.Errorf("field 55: got %T", spec.Fields.Get(55).Encoder)
+	}
+}
+
+func TestLoadSpecFromFileUnknownEncoder(t *testing.T) {
+	path := writeSpec(t, `
+fields:
+  41:
+    length: 8
+    encoder: "FAAlphanumeric"
+`)
+	_, err := LoadSpecFromFile(path)
+	if err == nil {
+		t.Fatal("expected error for unknown encoder")
+	}
+	if !strings.Contains(err.Error(), "field 41") || !strings.Contains(err.Error(), "FAAlphanumeric") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type upperChar struct{ field.FChar }
+
+func (u *upperChar) Pack(val string, length int) ([]byte, error) {
+	return u.FChar.Pack(strings.ToUpper(val), length)
+}
+
+func TestLoadSpecFromFileCustomEncoder(t *testing.T) {
+	field.Register("TestUpperChar", func() field.ISOField {
+		return &upperChar{}
+	})
+
+	path := writeSpec(t, `
+fields:
+  43:
+    length: 10
+    encoder: "TestUpperChar"
+`)
+	spec, err := LoadSpecFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+
+	packed, err := spec.Fields.Get(43).Encoder.Pack("shop", 10)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if string(packed) != "SHOP      " {
+		t.Errorf("got %q", packed)
+	}
+}
+
+func TestLoadSpecFromFileMTIAndBitmap(t *testing.T) {
+	spec, err := LoadSpecFromFile("../../spec.yaml")
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+	if _, ok := spec.MTIEncoder.(*field.FANumeric); !ok {
+		t.Errorf("mti: got %T", spec.MTIEncoder)
+	}
+	if _, ok := spec.BitmapEncoder.(*field.FABitmap); !ok {
+		t.Errorf("bitmap: got %T", spec.BitmapEncoder)
+	}
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(2, "4111111111111111")
+	msg.Set(3, "000000")
+	msg.Set(41, "TERM01")
+
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	want := "0200" + "6000000000800000" + "164111111111111111" + "000000" + "TERM01  "
+	if string(packed) != want {
+		t.Errorf("packed mismatch:\n got %q\nwant %q", packed, want)
+	}
+
+	result := NewMessage()
+	if err := result.Unpack(packed, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if result.Get(2) != "4111111111111111" || result.Get(41) != "TERM01  " {
+		t.Errorf("unexpected fields: %s", result.LogString())
+	}
+
+	binSpec, err := LoadSpecFromFile("../../iso87binary.yaml")
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+	if _, ok := binSpec.MTIEncoder.(*field.FBNumeric); !ok {
+		t.Errorf("mti: got %T", binSpec.MTIEncoder)
+	}
+}
+
+func TestLoadSpecFromFileUnknownBitmap(t *testing.T) {
+	path := writeSpec(t, `
+bitmap:
+  encoder: "FXBitmap"
+fields: {}
+`)
+	if _, err := LoadSpecFromFile(path); err == nil {
+		t.Fatal("expected error for unknown bitmap encoder")
+	}
+}
+
+func TestFABitmapSecondary(t *testing.T) {
+	bm := &field.FABitmap{}
+	packed, err := bm.Pack(map[int]bool{3: true, 70: true})
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if string(packed) != "A0000000000000000400000000000000" {
+		t.Errorf("got %s", packed)
+	}
+
+	fields, readLen, err := bm.Unpack(append(packed, "trailing"...))
+	if err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if readLen != 32 || !fields[3] || !fields[70] {
+		t.Errorf("readLen=%d fields=%v", readLen, fields)
+	}
+}
+
+func TestLoadSpecFromFileGenericVariable(t *testing.T) {
+	path := writeSpec(t, `
+fields:
+  55:
+    length: 255
+    prefix:
+      type: "binary"
+      bytes: 1
+    content: "tlv"
+  127:
+    length: 999999
+    prefix:
+      type: "ascii"
+      digits: 6
+  48:
+    length: 9999
+    encoder: "FALLLLChar"
+`)
+	spec, err := LoadSpecFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+
+	packed, err := spec.Fields.Get(55).Encoder.Pack("9F270180", 255)
+	if err != nil || string(packed) != "\x04\x9f\x27\x01\x80" {
+		t.Errorf("field 55: got % X, %v", packed, err)
+	}
+	packed, err = spec.Fields.Get(127).Encoder.Pack("ABC", 999999)
+	if err != nil || string(packed) != "000003ABC" {
+		t.Errorf("field 127: got %q, %v", packed, err)
+	}
+	packed, err = spec.Fields.Get(48).Encoder.Pack("ABC", 9999)
+	if err != nil || string(packed) != "0003ABC" {
+		t.Errorf("field 48: got %q, %v", packed, err)
+	}
+
+	path = writeSpec(t, `
+fields:
+  55:
+    length: 255
+    prefix:
+      type: "hex"
+      bytes: 1
+`)
+	if _, err := LoadSpecFromFile(path); err == nil {
+		t.Error("expected error for unknown prefix type")
+	}
+}
@@ -0,0 +1,122 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func strictSpec(strict bool) *Spec {
+	return withFields(testSpec(), map[int]FieldSpec{
+		2:  {Length: 19, Encoder: &field.FALLNumeric{}, Class: field.ClassNumeric, Strict: strict},
+		4:  {Length: 12, Encoder: &field.FANumeric{}, Class: field.ClassNumeric, Strict: strict},
+		41: {Length: 8, Encoder: &field.FChar{}, Class: field.ClassAlphanumeric, Strict: strict},
+	})
+}
+
+func TestStrictPack(t *testing.T) {
+	tests := []struct {
+		name  string
+		field int
+		value string
+	}{
+		{"oversized amount", 4, "1234567890123"},
+		{"letters in amount", 4, "00000000010A"},
+		{"short fixed field", 41, "TERM01"},
+		{"illegal character", 41, "TERM-001"},
+		{"letters in PAN", 2, "41111111X1111111"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := NewMessage()
+			msg.MTI = "0200"
+			msg.Set(tt.field, tt.value)
+
+			if _, err := msg.Pack(strictSpec(false)); err != nil {
+				t.Errorf("lenient pack failed: %v", err)
+			}
+
+			_, err := msg.Pack(strictSpec(true))
+			var pe *PackError
+			if !errors.As(err, &pe) || pe.Field != tt.field {
+				t.Errorf("expected strict PackError on field %d, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestStrictUnpack(t *testing.T) {
+	data := []byte("0200" + "1000000000800000" + "00000000010A" + "TERM0001")
+
+	if err := NewMessage().Unpack(data, strictSpec(false)); err != nil {
+		t.Fatalf("lenient unpack failed: %v", err)
+	}
+
+	err := NewMessage().Unpack(data, strictSpec(true))
+	var ue *UnpackError
+	if !errors.As(err, &ue) || ue.Field != 4 || ue.Offset != 20 {
+		t.Errorf("expected strict UnpackError on field 4 at offset 20, got %v", err)
+	}
+}
+
+func TestLoadSpecFromFileStrict(t *testing.T) {
+	path := writeSpec(t, `
+strict: true
+fields:
+  4:
+    length: 12
+    encoder: "FANumeric"
+    class: "n"
+  41:
+    length: 8
+    encoder: "FChar"
+    class: "ans"
+    strict: false
+`)
+	spec, err := LoadSpecFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpecFromFile failed: %v", err)
+	}
+	if f := spec.Fields.Get(4); !f.Strict || f.Class != field.ClassNumeric {
+		t.Errorf("field 4: got %+v", f)
+	}
+	if f := spec.Fields.Get(41); f.Strict || f.Class != field.ClassAlphanumericSpecial {
+		t.Errorf("field 41: got %+v", f)
+	}
+
+	path = writeSpec(t, `
+fields:
+  4:
+    length: 12
+    encoder: "FANumeric"
+    class: "num"
+`)
+	if _, err := LoadSpecFromFile(path); err == nil {
+		t.Error("expected error for unknown class")
+	}
+}
+
+// Track 2 in BCD keeps its separator through a strict pack and unpack
+func TestStrictTrack2(t *testing.T) {
+	spec := strictSpec(true)
+	spec.BitmapEncoder = &field.FBBitmap{}
+	spec.Fields.Set(35, FieldSpec{Length: 37, Encoder: &field.FBLLNumeric{}, Class: field.ClassTrack, Strict: true})
+
+	msg := NewMessage()
+	msg.MTI = "0200"
+	msg.Set(35, "4111111111111111D2512101")
+	data, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := NewMessage()
+	if err := got.Unpack(data, spec); err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(35); !strings.EqualFold(v, "4111111111111111D2512101") {
+		t.Errorf("field 35 = %q", v)
+	}
+}
@@ -0,0 +1,61 @@
+package iso8583
+
+import "GoSwitch/pkg/field"
+
+// testSpec is the spec shared by the tests: ASCII MTI and bitmap, the STAN
+// and one composite of each layout, 48 (tagged), 60 (positional) and 127
+// (sub-bitmapped). Tests add the fields they need with withFields.
+func testSpec() *Spec {
+	return &Spec{
+		MTIEncoder:    &field.FANumeric{},
+		BitmapEncoder: &field.FABitmap{},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			11: {Length: 6, Description: "STAN", Encoder: &field.FANumeric{}},
+			48: {
+				Length:      999,
+				Description: "Additional Data - Private",
+				Encoder:     &field.FALLLChar{},
+				Subfields: &Spec{
+					TagLength: 2,
+					Fields: *NewFieldTable(map[int]FieldSpec{
+						1:  {Length: 99, Description: "Merchant Name", Encoder: &field.FALLChar{}},
+						42: {Length: 3, Description: "ECI", Encoder: &field.FANumeric{}},
+					}),
+				},
+			},
+			60: {
+				Length:      999,
+				Description: "Terminal Data",
+				Encoder:     &field.FALLLChar{},
+				Subfields: &Spec{
+					Fields: *NewFieldTable(map[int]FieldSpec{
+						1: {Length: 4, Description: "Batch", Encoder: &field.FANumeric{}},
+						2: {Length: 8, Description: "Serial", Encoder: &field.FChar{}},
+						3: {Length: 20, Description: "Version", Encoder: &field.FALLChar{}},
+					}),
+				},
+			},
+			127: {
+				Length:      999,
+				Description: "Private Use",
+				Encoder:     &field.FALLLChar{},
+				Subfields: &Spec{
+					BitmapEncoder: &field.FABitmap{},
+					Fields: *NewFieldTable(map[int]FieldSpec{
+						2:  {Length: 32, Description: "Switch Key", Encoder: &field.FALLChar{}},
+						3:  {Length: 48, Description: "Routing", Encoder: &field.FChar{}},
+						22: {Length: 999, Description: "Structured Data", Encoder: &field.FALLLChar{}},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+// withFields adds fields to spec, replacing those it already has
+func withFields(spec *Spec, fields map[int]FieldSpec) *Spec {
+	for id, fSpec := range fields {
+		spec.Fields.Set(id, fSpec)
+	}
+	return spec
+}
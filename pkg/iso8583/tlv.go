@@ -0,0 +1,111 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Tags parses a TLV field (e.g., field 55 packed with FBLLLTLV) into its data objects in wire order
+func (m *Message) Tags(fieldNum int) ([]field.TLV, error) {
+	f, exists := m.Fields.Lookup(fieldNum)
+	if !exists {
+		return nil, nil
+	}
+
+	tags, err := decodeTags(f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("field %d: %w", fieldNum, err)
+	}
+	return tags, nil
+}
+
+// SetTags replaces a TLV field with the given data objects, keeping their order
+func (m *Message) SetTags(fieldNum int, tags []field.TLV) error {
+	val, err := encodeTags(tags)
+	if err != nil {
+		return fmt.Errorf("field %d: %w", fieldNum, err)
+	}
+	m.Set(fieldNum, val)
+	return nil
+}
+
+// GetTag retrieves the value of a single tag, e.g., m.GetTag(55, "9F26")
+func (m *Message) GetTag(fieldNum int, tag string) ([]byte, bool) {
+	tags, err := m.Tags(fieldNum)
+	if err != nil {
+		return nil, false
+	}
+
+	tag = strings.ToUpper(tag)
+	for _, t := range tags {
+		if t.Tag == tag {
+			return t.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetTag updates a tag in place, or appends it if the field does not carry it yet.
+// Only that tag is re-encoded; the others keep their bytes, padding included.
+func (m *Message) SetTag(fieldNum int, tag string, value []byte) error {
+	tags, err := m.Tags(fieldNum)
+	if err != nil {
+		return err
+	}
+
+	tag = strings.ToUpper(tag)
+	found := false
+	for i := range tags {
+		if tags[i].Tag == tag {
+			tags[i].Value = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		tags = append(tags, field.TLV{Tag: tag, Value: value})
+	}
+	return m.SetTags(fieldNum, tags)
+}
+
+// UnsetTag removes a tag from a TLV field; the field is removed when it becomes empty
+func (m *Message) UnsetTag(fieldNum int, tag string) error {
+	tags, err := m.Tags(fieldNum)
+	if err != nil {
+		return err
+	}
+
+	tag = strings.ToUpper(tag)
+	kept := tags[:0]
+	for _, t := range tags {
+		if t.Tag != tag {
+			kept = append(kept, t)
+		}
+	}
+
+	if len(kept) == 0 {
+		m.Unset(fieldNum)
+		return nil
+	}
+	return m.SetTags(fieldNum, kept)
+}
+
+// decodeTags parses a TLV field value, which holds the hex of the wire bytes
+func decodeTags(value []byte) ([]field.TLV, error) {
+	data, err := hex.DecodeString(string(value))
+	if err != nil {
+		return nil, fmt.Errorf("not hex encoded TLV data: %v", err)
+	}
+	return field.ParseTLV(data)
+}
+
+// encodeTags is the inverse of decodeTags
+func encodeTags(tags []field.TLV) (string, error) {
+	data, err := field.EncodeTLV(tags)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
@@ -0,0 +1,75 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"bytes"
+	"testing"
+)
+
+func TestMessageTags(t *testing.T) {
+	spec := &Spec{
+		MTIEncoder:    &field.FBNumeric{},
+		BitmapEncoder: &field.FBBitmap{},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			55: {Length: 255, Description: "ICC Data", Encoder: &field.FBLLLTLV{}},
+		}),
+	}
+
+	wire := []byte{
+		0x02, 0x00, // MTI
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, // Bitmap: field 55
+		0x00, 0x19, // LLL = 19
+		0x9F, 0x26, 0x08, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88,
+		0x9F, 0x27, 0x01, 0x80,
+		0x82, 0x02, 0x19, 0x80,
+	}
+
+	msg := NewMessage()
+	if err := msg.Unpack(wire, spec); err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+
+	tags, err := msg.Tags(55)
+	if err != nil {
+		t.Fatalf("Tags failed: %v", err)
+	}
+	if len(tags) != 3 || tags[0].Tag != "9F26" || tags[1].Tag != "9F27" || tags[2].Tag != "82" {
+		t.Fatalf("unexpected tags: %+v", tags)
+	}
+
+	if v, ok := msg.GetTag(55, "9f27"); !ok || !bytes.Equal(v, []byte{0x80}) {
+		t.Errorf("GetTag 9F27: got % X, %v", v, ok)
+	}
+
+	// Unchanged tags repack byte-exactly
+	packed, err := msg.Pack(spec)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if !bytes.Equal(packed, wire) {
+		t.Errorf("repack differs:\n got % X\nwant % X", packed, wire)
+	}
+
+	// Updates keep the order, new tags are appended
+	if err := msg.SetTag(55, "9F27", []byte{0x40}); err != nil {
+		t.Fatalf("SetTag failed: %v", err)
+	}
+	if err := msg.SetTag(55, "9A", []byte{0x25, 0x10, 0x18}); err != nil {
+		t.Fatalf("SetTag failed: %v", err)
+	}
+	if err := msg.UnsetTag(55, "82"); err != nil {
+		t.Fatalf("UnsetTag failed: %v", err)
+	}
+	if got := msg.Get(55); got != "9F260811223344556677889F2701409A03251018" {
+		t.Errorf("field 55: got %s", got)
+	}
+
+	// Padding and long-form lengths on the other tags survive an edit
+	msg.Set(55, "9F2608112233445566778800"+"9F27810140"+"FFFF"+"9A03251018")
+	if err := msg.SetTag(55, "9A", []byte{0x25, 0x10, 0x19}); err != nil {
+		t.Fatalf("SetTag failed: %v", err)
+	}
+	if got := msg.Get(55); got != "9F2608112233445566778800"+"9F27810140"+"FFFF"+"9A03251019" {
+		t.Errorf("field 55: got %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"errors"
+	"fmt"
+)
+
+// Validate checks the spec for consistency and reports every problem found,
+// each prefixed with the field it concerns. LoadSpecFromFile runs it; call it
+// at startup for specs built in code.
+func (s *Spec) Validate() error {
+	var errs []error
+	if s.MTIEncoder == nil {
+		errs = append(errs, fmt.Errorf("mti: missing encoder"))
+	}
+	if len(s.Versions) > 0 {
+		for _, v := range sortedVersions(s.Versions) {
+			if err := s.Versions[v].Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("version %c: %w", v, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if s.BitmapEncoder == nil {
+		errs = append(errs, fmt.Errorf("bitmap: missing encoder"))
+	}
+	validateFields(s, "", &errs)
+	return errors.Join(errs...)
+}
+
+// validateFields checks the (sub)fields of s; prefix carries the parent path
+func validateFields(s *Spec, prefix string, errs *[]error) {
+	fail := func(id int, format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("field %s%d: %s", prefix, id, fmt.Sprintf(format, args...)))
+	}
+
+	maxField := 0
+	if s.BitmapEncoder != nil {
+		maxField = s.BitmapEncoder.MaxField()
+	}
+
+	for id, fSpec := range s.Fields.All() {
+		switch {
+		case id < 0, id == 0 && s.BitmapEncoder != nil:
+			// Positional and tagged composites may number subfields from 0
+			fail(id, "invalid field number")
+		case s.BitmapEncoder != nil && id == 1:
+			fail(id, "reserved for the secondary bitmap indicator")
+		case s.BitmapEncoder != nil && isBitmapIndicator(id, maxField):
+			fail(id, "reserved for the tertiary bitmap indicator")
+		case s.BitmapEncoder != nil && id > maxField:
+			fail(id, "beyond the %d fields the bitmap can address", maxField)
+		case s.TagLength > 0 && len(fmt.Sprint(id)) > s.TagLength:
+			fail(id, "number does not fit in a %d digit tag", s.TagLength)
+		}
+
+		if fSpec.Encoder == nil {
+			fail(id, "missing encoder")
+		}
+		if fSpec.Length <= 0 {
+			fail(id, "length must be positive, got %d", fSpec.Length)
+		}
+		if l, ok := fSpec.Encoder.(field.LengthLimited); ok && fSpec.Length > l.MaxLength() {
+			fail(id, "length %d exceeds the %d its length prefix can express", fSpec.Length, l.MaxLength())
+		}
+
+		if _, err := field.ParseClass(string(fSpec.Class)); err != nil {
+			fail(id, "%v", err)
+		}
+		if _, err := ParseMask(string(fSpec.Mask)); err != nil {
+			fail(id, "%v", err)
+		}
+
+		if sub := fSpec.Subfields; sub != nil {
+			if sub.BitmapEncoder != nil && sub.TagLength > 0 {
+				fail(id, "composite cannot have both a bitmap and a tag length")
+			}
+			validateFields(sub, fmt.Sprintf("%s%d.", prefix, id), errs)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package iso8583
+
+import (
+	"GoSwitch/pkg/field"
+	"strings"
+	"testing"
+)
+
+func TestSpecValidate(t *testing.T) {
+	if err := testSpec().Validate(); err != nil {
+		t.Fatalf("expected valid spec, got %v", err)
+	}
+
+	spec := &Spec{
+		BitmapEncoder: &field.FBBitmap{Tertiary: true},
+		Fields: *NewFieldTable(map[int]FieldSpec{
+			1:   {Length: 8, Encoder: &field.FBBinary{}},
+			2:   {Length: 120, Encoder: &field.FBLLNumeric{}},
+			4:   {Length: 12},
+			11:  {Encoder: &field.FANumeric{}},
+			65:  {Length: 8, Encoder: &field.FBBinary{}},
+			193: {Length: 3, Encoder: &field.FANumeric{}},
+			127: {
+				Length:  999,
+				Encoder: &field.FALLLChar{},
+				Subfields: &Spec{
+					TagLength: 2,
+					Fields: *NewFieldTable(map[int]FieldSpec{
+						100: {Length: 3, Encoder: &field.FANumeric{}},
+						5:   {Length: 1000, Encoder: &field.FALLLChar{}},
+					}),
+				},
+			},
+		}),
+	}
+
+	err := spec.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"mti: missing encoder",
+		"field 1: reserved",
+		"field 2: length 120 exceeds the 99",
+		"field 4: missing encoder",
+		"field 11: length must be positive",
+		"field 65: reserved",
+		"field 193: beyond the 192 fields",
+		"field 127.5: length 1000 exceeds the 999",
+		"field 127.100: number does not fit in a 2 digit tag",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadSpecFromFileInvalid(t *testing.T) {
+	path := writeSpec(t, `
+fields:
+  2:
+    length: 200
+    encoder: "FALLNumeric"
+  11:
+    encoder: "FANumeric"
+`)
+	_, err := LoadSpecFromFile(path)
+	if err == nil || !strings.Contains(err.Error(), "field 2:") || !strings.Contains(err.Error(), "field 11:") {
+		t.Errorf("expected both fields reported, got %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package server
 
 import (
 	"GoSwitch/pkg/iso8583"
+	"context"
 	"io"
+	"log/slog"
 	"net"
 )
 
@@ -21,3 +23,13 @@ type LengthHandler interface {
 	ReadLength(r io.Reader) (int, error)
 	WriteLength(w io.Writer, length int) error
 }
+
+// logFrame logs an annotated dump of the ISO payload of a frame at Debug
+// level, before it is unpacked on the way in and after it is packed on the way out
+func logFrame(channel, direction string, data []byte, spec *iso8583.Spec) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	slog.Debug("Frame dump", "channel", channel, "direction", direction, "bytes", len(data),
+		"dump", iso8583.Dump(data, spec))
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConcurrentSends hammers one session with concurrent requests, each
+// answered from its own handler goroutine; run with -race. Every reply must
+// arrive as an intact frame.
+func TestConcurrentSends(t *testing.T) {
+	for _, queueSize := range []int{0, 4} {
+		t.Run(fmt.Sprintf("queue=%d", queueSize), func(t *testing.T) {
+			spec := loadSpec(t)
+			prototype := NewBASE24TCPChannel(nil, spec).(*FramedChannel)
+			prototype.QueueSize = queueSize
+
+			e := NewEngine("", spec, prototype)
+			e.Request(func(c *Context) {
+				resp := echoRequest()
+				resp.MTI = "0810"
+				resp.Set(11, c.Request.Get(11))
+				if err := c.Send(resp); err != nil {
+					t.Error(err)
+				}
+			})
+
+			client, conn := net.Pipe()
+			defer client.Close()
+			e.track(conn, true)
+			go e.serve(conn, &Listener{Name: "test", Channel: prototype, Spec: spec})
+
+			const requests = 200
+			ch := prototype.Clone(client)
+			var wg sync.WaitGroup
+			for i := range requests {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := echoRequest()
+					req.Set(11, fmt.Sprintf("%06d", i))
+					if err := ch.Send(req); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+
+			seen := make(map[string]bool)
+			for range requests {
+				resp, err := ch.Receive(client)
+				if err != nil {
+					t.Fatalf("after %d replies: %v", len(seen), err)
+				}
+				seen[resp.Get(11)] = true
+			}
+			wg.Wait()
+			if len(seen) != requests {
+				t.Errorf("%d distinct replies, want %d", len(seen), requests)
+			}
+		})
+	}
+}
+
+func TestQueueFlushedOnClose(t *testing.T) {
+	spec := loadSpec(t)
+	prototype := NewNACChannel(nil, spec).(*FramedChannel)
+	prototype.QueueSize = 8
+
+	client, conn := net.Pipe()
+	defer client.Close()
+	ch := prototype.Clone(conn).(*FramedChannel)
+
+	for range 3 {
+		if err := ch.Send(echoRequest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	received := make(chan int)
+	go func() {
+		reader := prototype.Clone(client)
+		n := 0
+		for ; n < 3; n++ {
+			if _, err := reader.Receive(client); err != nil {
+				break
+			}
+		}
+		received <- n
+	}()
+
+	if err := ch.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-received; n != 3 {
+		t.Errorf("%d frames flushed, want 3", n)
+	}
+	if err := ch.Send(echoRequest()); err == nil {
+		t.Error("Send after Close succeeded")
+	}
+}
+
+// TestSendRacingClose checks that every Send reporting success while Close
+// runs reaches the wire, the others failing with ErrChannelClosed
+func TestSendRacingClose(t *testing.T) {
+	spec := loadSpec(t)
+	prototype := NewNACChannel(nil, spec).(*FramedChannel)
+	prototype.QueueSize = 64
+
+	for range 100 {
+		client, conn := net.Pipe()
+		ch := prototype.Clone(conn)
+		received := make(chan int)
+		go func() {
+			reader := NewNACChannel(client, spec)
+			n := 0
+			for ; ; n++ {
+				if _, err := reader.Receive(client); err != nil {
+					break
+				}
+			}
+			received <- n
+		}()
+
+		var sent atomic.Int32
+		var wg sync.WaitGroup
+		for range 16 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					err := ch.Send(echoRequest())
+					if err != nil {
+						if !errors.Is(err, ErrChannelClosed) {
+							t.Error(err)
+						}
+						return
+					}
+					sent.Add(1)
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		if err := ch.(*FramedChannel).Close(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		conn.Close()
+
+		if n := <-received; n != int(sent.Load()) {
+			t.Fatalf("%d frames received, %d sends succeeded", n, sent.Load())
+		}
+		client.Close()
+	}
+}
+
+// TestPeerNotReading checks that a client which stops reading its replies
+// cannot hold a session, and with it Shutdown, past the write timeout
+func TestPeerNotReading(t *testing.T) {
+	spec := loadSpec(t)
+	prototype := NewNACChannel(nil, spec).(*FramedChannel)
+	prototype.QueueSize = 1
+	prototype.WriteTimeout = 50 * time.Millisecond
+
+	e := NewEngine("", spec, prototype)
+	started := make(chan struct{})
+	e.Request(func(c *Context) {
+		close(started)
+		// More replies than the queue holds, none of them read
+		for range 4 {
+			resp := echoRequest()
+			resp.MTI = "0810"
+			c.Send(resp)
+		}
+	})
+
+	client, conn := net.Pipe()
+	defer client.Close()
+	e.track(conn, true)
+	go e.serve(conn, &Listener{Name: "test", Channel: prototype, Spec: spec})
+
+	if err := prototype.Clone(client).Send(echoRequest()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+}
@@ -2,11 +2,16 @@ package server
 
 import (
 	"GoSwitch/pkg/iso8583"
+	"context"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
 type Context struct {
+	// Ctx is cancelled when the connection is lost or the engine stops; it
+	// outlives a graceful Shutdown until the handler returns
+	Ctx     context.Context
 	Request *iso8583.Message
 	Channel Channel
 	Spec    *iso8583.Spec
@@ -14,8 +19,9 @@ type Context struct {
 	Engine  *Engine
 }
 
-func NewContext(request *iso8583.Message, channel Channel, spec *iso8583.Spec, logger *slog.Logger, engine *Engine) *Context {
+func NewContext(ctx context.Context, request *iso8583.Message, channel Channel, spec *iso8583.Spec, logger *slog.Logger, engine *Engine) *Context {
 	return &Context{
+		Ctx:     ctx,
 		Request: request,
 		Channel: channel,
 		Spec:    spec,
@@ -26,6 +32,12 @@ func NewContext(request *iso8583.Message, channel Channel, spec *iso8583.Spec, l
 
 // Send packs the message and sends it back using the configured channel
 func (c *Context) Send(msg *iso8583.Message) error {
-	c.Slog.Info(fmt.Sprintf("Outgoing: %s", msg.LogString()))
+	c.Slog.Info(fmt.Sprintf("Outgoing: %s", msg.LogStringWith(c.Spec)))
 	return c.Channel.Send(msg)
 }
+
+// SendAndReceive sends req to a peer and waits for its response, giving up
+// early when Ctx is cancelled
+func (c *Context) SendAndReceive(peerName string, req *iso8583.Message, timeout time.Duration) (*iso8583.Message, error) {
+	return c.Engine.SendAndReceiveContext(c.Ctx, peerName, req, timeout)
+}
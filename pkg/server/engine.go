@@ -1,7 +1,10 @@
 package server
 
 import (
+	"GoSwitch/pkg/config"
 	"GoSwitch/pkg/iso8583"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,19 +12,52 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type HandleFunc func(*Context)
 
+// ErrEngineClosed is returned by Start after Shutdown
+var ErrEngineClosed = errors.New("engine closed")
+
+// Engine serves its listeners and keeps its outgoing peers connected. Without
+// listeners added through Listen, it listens on Addr with Channel and Spec.
 type Engine struct {
-	Addr             string
-	Spec             *iso8583.Spec
-	Channel          Channel
+	Addr        string
+	Spec        *iso8583.Spec
+	Channel     Channel
+	ReadTimeout time.Duration // For the Addr listener
+	// SignOff builds the message sent to every connected peer on Shutdown;
+	// nil, or a nil message, skips the peer
+	SignOff          func(peer *Peer) *iso8583.Message
 	requestHandler   HandleFunc
+	handlers         map[string]HandleFunc
+	listeners        []*Listener
 	slog             *slog.Logger
 	Peers            sync.Map
+	peerSessions     sync.Map // Peer name -> *peerSession
 	pendingResponses sync.Map
+	stan             atomic.Uint32
+
+	// ctx is the parent of every connection and handler context; cancel
+	// ends the engine for good
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	closing  bool
+	lns      []net.Listener
+	conns    map[net.Conn]bool // Open connections, true for incoming ones
+	sessions sync.WaitGroup    // serve loops, each waiting for its handlers
+	peers    sync.WaitGroup    // ConnectPeer loops
+}
+
+// peerSession is the live connection to a peer
+type peerSession struct {
+	peer    *Peer
+	channel Channel
+	done    chan struct{} // Closed when the connection is lost
 }
 
 func NewEngine(addr string, spec *iso8583.Spec, channel Channel) *Engine {
@@ -37,104 +73,385 @@ func NewEngine(addr string, spec *iso8583.Spec, channel Channel) *Engine {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, opts))
 	slog.SetDefault(logger)
 
-	return &Engine{
-		Addr:    addr,
-		Spec:    spec,
-		Channel: channel,
-		slog:    logger,
+	e := &Engine{
+		Addr:     addr,
+		Spec:     spec,
+		Channel:  channel,
+		handlers: make(map[string]HandleFunc),
+		slog:     logger,
+		conns:    make(map[net.Conn]bool),
 	}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	e.SignOff = e.signOffMessage
+	return e
 }
 
+// Request sets the handler of listeners that name none
 func (e *Engine) Request(h HandleFunc) {
 	e.requestHandler = h
 }
 
-func (e *Engine) Start() error {
-	ln, err := net.Listen("tcp", e.Addr)
-	if err != nil {
+// Handle registers a handler that listeners can name in app.yaml
+func (e *Engine) Handle(name string, h HandleFunc) {
+	e.handlers[name] = h
+}
+
+// Listen adds a listener, served from Start on
+func (e *Engine) Listen(l *Listener) {
+	e.listeners = append(e.listeners, l)
+}
+
+// Start serves the listeners until Shutdown, returning ErrEngineClosed, or
+// until ctx is done, returning its error after closing every connection
+// without draining
+func (e *Engine) Start(ctx context.Context) error {
+	listeners := e.listeners
+	if len(listeners) == 0 {
+		listeners = []*Listener{{
+			Name:        "default",
+			Addr:        e.Addr,
+			Channel:     e.Channel,
+			Spec:        e.Spec,
+			ReadTimeout: e.ReadTimeout,
+		}}
+	}
+
+	// Bind every address before serving any, so a taken port fails Start
+	lns := make([]net.Listener, len(listeners))
+	for i, l := range listeners {
+		ln, err := net.Listen("tcp", l.Addr)
+		if err != nil {
+			for _, open := range lns[:i] {
+				open.Close()
+			}
+			return fmt.Errorf("listener %s: %w", l.Name, err)
+		}
+		lns[i] = ln
+	}
+
+	e.mu.Lock()
+	if e.closing {
+		e.mu.Unlock()
+		for _, ln := range lns {
+			ln.Close()
+		}
+		return ErrEngineClosed
+	}
+	e.lns = append(e.lns, lns...)
+	e.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, e.stop)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i, l := range listeners {
+		e.slog.Info("GoSwitch Framework listening", "listener", l.Name, "addr", l.Addr)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.accept(lns[i], l)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	e.slog.Info("GoSwitch Framework listening", "addr", e.Addr)
+	return ErrEngineClosed
+}
+
+// Shutdown stops accepting connections and reading requests, waits for the
+// handlers in flight, signs off from the peers and closes every connection.
+// If ctx is done first, the remaining handlers see their context cancelled,
+// connections are closed anyway and ctx's error is returned.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	e.closing = true
+	for _, ln := range e.lns {
+		ln.Close()
+	}
+	// Interrupt the reads of incoming sessions; replies can still be sent
+	for conn, incoming := range e.conns {
+		if incoming {
+			conn.SetReadDeadline(time.Now())
+		}
+	}
+	e.mu.Unlock()
+	e.slog.Info("Shutting down")
 
+	err := waitContext(ctx, &e.sessions)
+	if err == nil {
+		e.signOff(ctx)
+	}
+	e.stop()
+	if werr := waitContext(ctx, &e.peers); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// stop ends the engine at once: contexts cancelled, every connection closed
+func (e *Engine) stop() {
+	e.cancel()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closing = true
+	for _, ln := range e.lns {
+		ln.Close()
+	}
+	for conn := range e.conns {
+		conn.Close()
+	}
+}
+
+// waitContext waits for wg unless ctx is done first
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track registers an open connection, counting incoming ones as sessions
+// for Shutdown to wait for, or closes it when the engine is closing
+func (e *Engine) track(conn net.Conn, incoming bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closing {
+		conn.Close()
+		return false
+	}
+	e.conns[conn] = incoming
+	if incoming {
+		e.sessions.Add(1)
+	}
+	return true
+}
+
+func (e *Engine) untrack(conn net.Conn) {
+	e.mu.Lock()
+	delete(e.conns, conn)
+	e.mu.Unlock()
+	conn.Close()
+}
+
+// endSession flushes the channel of a session, if it has an outbound queue,
+// then closes its connection. The write deadline keeps a peer that stopped
+// reading from holding up the flush, and with it Shutdown.
+func (e *Engine) endSession(conn net.Conn, ch Channel) {
+	conn.SetWriteDeadline(time.Now().Add(DefaultWriteTimeout))
+	if c, ok := ch.(io.Closer); ok {
+		c.Close()
+	}
+	e.untrack(conn)
+}
+
+// nextRead arms the read deadline of an incoming session for its next
+// request, reporting false once the engine is shutting down
+func (e *Engine) nextRead(conn net.Conn, timeout time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closing {
+		return false
+	}
+	if timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+	return true
+}
+
+func (e *Engine) accept(ln net.Listener, l *Listener) {
+	var delay time.Duration
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
-			slog.Error("Accept error", "error", err)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			// Out of file descriptors and the like: retry, backing off up to 1s
+			if delay == 0 {
+				delay = 5 * time.Millisecond
+			} else if delay *= 2; delay > time.Second {
+				delay = time.Second
+			}
+			e.slog.Error("Accept error", "listener", l.Name, "error", err, "retry_in", delay)
+			time.Sleep(delay)
 			continue
 		}
-		go e.serve(conn)
-		// Unified loop for incoming connections
-		// go e.managePeer(conn, conn.RemoteAddr().String(), true)
+		delay = 0
+
+		if !e.track(conn, true) {
+			return
+		}
+		go e.serve(conn, l)
 	}
 }
 
-func (e *Engine) serve(conn net.Conn) {
-	defer conn.Close()
+// serve reads the requests of an incoming session registered with track
+func (e *Engine) serve(conn net.Conn, listener *Listener) {
+	defer e.sessions.Done()
+
+	ctx, cancel := context.WithCancel(e.ctx)
+	sessionChannel := listener.Channel.Clone(conn)
+	var handlers sync.WaitGroup
+	defer func() {
+		// Replies of the handlers still running go out before the close
+		handlers.Wait()
+		cancel()
+		e.endSession(conn, sessionChannel)
+	}()
 
-	sessionChannel := e.Channel.Clone(conn)
-	l := e.slog.With("remote_addr", conn.RemoteAddr())
+	l := e.slog.With("listener", listener.Name, "remote_addr", conn.RemoteAddr())
 	l.Info("New connection")
 
-	for {
+	handler := listener.Handler
+	if handler == nil {
+		handler = e.requestHandler
+	}
+
+	for e.nextRead(conn, listener.ReadTimeout) {
 		msg, err := sessionChannel.Receive(conn)
 		if err != nil {
-			if err != io.EOF {
-				l.Error("read error", "err", err)
+			if !e.isClosing() {
+				if err != io.EOF {
+					l.Error("read error", "err", err)
+				}
+				// Connection lost: handlers still running are cancelled
+				cancel()
 			}
 			break
 		}
 		l.Info(fmt.Sprintf("Incoming: %s", msg.LogString()))
 		// Create Context
-		ctx := NewContext(msg, sessionChannel, e.Spec, l, e)
+		c := NewContext(ctx, msg, sessionChannel, listener.Spec, l, e)
 
 		// Execute User Logic
-		if e.requestHandler != nil {
+		if handler != nil {
+			handlers.Add(1)
 			go func() {
+				defer handlers.Done()
 				defer func() {
 					if r := recover(); r != nil {
 						l.Error("Panic in request handler", "reason", r)
 					}
 				}()
-				e.requestHandler(ctx)
+				handler(c)
 			}()
 		}
 	}
 }
 
-// Connect adds an Outgoing Peer (Client)
+func (e *Engine) isClosing() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closing
+}
+
+// Connect adds an Outgoing Peer (Client) using the engine's channel and spec
 func (e *Engine) Connect(name string, addr string) {
+	e.ConnectPeer(&Peer{
+		Name:    name,
+		Addr:    addr,
+		Channel: e.Channel,
+		Spec:    e.Spec,
+		Backoff: DefaultBackoff,
+	})
+}
+
+// ConnectPeers connects the enabled peers of app.yaml
+func (e *Engine) ConnectPeers(cfgs []config.ChannelConfig) error {
+	var peers []*Peer
+	for _, cfg := range cfgs {
+		if !cfg.Enabled {
+			e.slog.Info("Peer disabled", "name", cfg.Name)
+			continue
+		}
+		peer, err := NewPeer(cfg, e.Spec)
+		if err != nil {
+			return err
+		}
+		peers = append(peers, peer)
+	}
+	for _, peer := range peers {
+		e.ConnectPeer(peer)
+	}
+	return nil
+}
+
+// ConnectPeer keeps a connection to peer up, reconnecting with its backoff,
+// until the engine stops
+func (e *Engine) ConnectPeer(peer *Peer) {
+	e.peers.Add(1)
 	go func() {
-		for {
-			conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
-			if err != nil {
-				e.slog.Error("Failed to connect to peer", "name", name, "addr", addr)
-				time.Sleep(5 * time.Second)
-				continue
+		defer e.peers.Done()
+		dialer := net.Dialer{Timeout: 10 * time.Second}
+		for attempt := 0; ; attempt++ {
+			conn, err := dialer.DialContext(e.ctx, "tcp", peer.Addr)
+			if err == nil && e.track(conn, false) {
+				// Manage the outgoing peer
+				e.managePeer(conn, peer)
+				attempt = 0
+			}
+			if e.ctx.Err() != nil {
+				return
 			}
 
-			// Manage the outgoing peer
-			e.managePeer(conn, name, false)
-			e.slog.Warn("Peer connection lost, retrying...", "name", name)
-			time.Sleep(5 * time.Second)
+			delay := peer.Backoff.Delay(attempt)
+			if err != nil {
+				e.slog.Error("Failed to connect to peer", "name", peer.Name, "addr", peer.Addr, "err", err, "retry_in", delay)
+			} else {
+				e.slog.Warn("Peer connection lost, retrying...", "name", peer.Name, "retry_in", delay)
+			}
+			select {
+			case <-time.After(delay):
+			case <-e.ctx.Done():
+				return
+			}
 		}
 	}()
 }
 
 // managePeer is the centralized reader loop for EVERY connection
-func (e *Engine) managePeer(conn net.Conn, name string, isIncoming bool) {
-	defer conn.Close()
-
-	sessionChannel := e.Channel.Clone(conn)
+func (e *Engine) managePeer(conn net.Conn, peer *Peer) {
+	name := peer.Name
+	sessionChannel := peer.Channel.Clone(conn)
+	defer e.endSession(conn, sessionChannel)
+	session := &peerSession{peer: peer, channel: sessionChannel, done: make(chan struct{})}
 	e.Peers.Store(name, sessionChannel)
-	defer e.Peers.Delete(name)
+	e.peerSessions.Store(name, session)
+	defer func() {
+		e.Peers.Delete(name)
+		e.peerSessions.Delete(name)
+		close(session.done)
+	}()
 
-	e.slog.Info("Peer active", "name", name, "incoming", isIncoming)
+	e.slog.Info("Peer active", "name", name, "addr", peer.Addr)
 
 	for {
 		msg, err := sessionChannel.Receive(conn)
-		e.slog.Info("listening for messages...")
 		if err != nil {
-			e.slog.Error("read error", "err", err, "peer", name)
+			if e.ctx.Err() != nil {
+				break
+			}
+			var ue *iso8583.UnpackError
+			if errors.As(err, &ue) {
+				attrs := []any{"err", err, "peer", name,
+					"field", ue.Path, "offset", ue.Offset, "encoder", ue.Encoder}
+				// The bytes around a broken field may hold card data
+				if peer.Spec != nil && peer.Spec.LogClear {
+					attrs = append(attrs, "raw", fmt.Sprintf("% X", ue.Raw), "raw_from", ue.RawFrom)
+				}
+				e.slog.Error("read error", attrs...)
+			} else {
+				e.slog.Error("read error", "err", err, "peer", name)
+			}
 			break
 		}
 
@@ -153,18 +470,81 @@ func (e *Engine) managePeer(conn net.Conn, name string, isIncoming bool) {
 	}
 }
 
+// signOff sends the SignOff message to every connected peer and waits for
+// the replies, within ctx
+func (e *Engine) signOff(ctx context.Context) {
+	if e.SignOff == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	e.peerSessions.Range(func(_, val any) bool {
+		session := val.(*peerSession)
+		msg := e.SignOff(session.peer)
+		if msg == nil {
+			return true
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.SendAndReceiveContext(ctx, session.peer.Name, msg, 5*time.Second); err != nil {
+				e.slog.Warn("Sign-off failed", "peer", session.peer.Name, "err", err)
+				return
+			}
+			e.slog.Info("Signed off", "peer", session.peer.Name)
+		}()
+		return true
+	})
+	wg.Wait()
+}
+
+// signOffMessage is the default SignOff: a network management request with
+// function code 002 in field 70, for peers whose spec defines it
+func (e *Engine) signOffMessage(peer *Peer) *iso8583.Message {
+	if peer.Spec == nil {
+		return nil
+	}
+	for _, id := range []int{11, 70} {
+		if !peer.Spec.Fields.Has(id) {
+			return nil
+		}
+	}
+
+	msg := iso8583.NewMessage()
+	msg.MTI = "0800"
+	if peer.Spec.Fields.Has(7) {
+		msg.Set(7, time.Now().UTC().Format("0102150405"))
+	}
+	msg.Set(11, e.NextSTAN())
+	msg.Set(70, "002")
+	return msg
+}
+
+// NextSTAN returns a system trace audit number for messages the engine originates
+func (e *Engine) NextSTAN() string {
+	return fmt.Sprintf("%06d", e.stan.Add(1)%1000000)
+}
+
 func (e *Engine) SendAndReceive(peerName string, req *iso8583.Message, timeout time.Duration) (*iso8583.Message, error) {
+	return e.SendAndReceiveContext(context.Background(), peerName, req, timeout)
+}
+
+// SendAndReceiveContext is SendAndReceive giving up early when ctx is done,
+// the connection to the peer is lost or the engine stops
+func (e *Engine) SendAndReceiveContext(ctx context.Context, peerName string, req *iso8583.Message, timeout time.Duration) (*iso8583.Message, error) {
 	// 1. Find the target connection
-	val, ok := e.Peers.Load(peerName)
+	val, ok := e.peerSessions.Load(peerName)
 	if !ok {
 		return nil, fmt.Errorf("session not found for address: %s", peerName)
 	}
-	sessionChannel := val.(Channel)
+	session := val.(*peerSession)
 
 	// 2. Setup correlation (STAN)
 	stan := req.Get(11)
 
-	respMTI := predictResponseMTI(req.MTI)
+	respMTI, err := iso8583.ResponseMTI(req.MTI)
+	if err != nil {
+		return nil, err
+	}
 	ticket := e.createTicket(respMTI, stan)
 
 	respChan := make(chan *iso8583.Message, 1)
@@ -172,47 +552,27 @@ func (e *Engine) SendAndReceive(peerName string, req *iso8583.Message, timeout t
 	defer e.pendingResponses.Delete(ticket)
 
 	// 3. Send
-	if err := sessionChannel.Send(req); err != nil {
+	if err := session.channel.Send(req); err != nil {
 		return nil, err
 	}
 
 	// 4. Wait
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 	select {
 	case resp := <-respChan:
 		return resp, nil
-	case <-time.After(timeout):
+	case <-timer.C:
 		return nil, fmt.Errorf("timeout waiting for STAN %s", ticket)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-session.done:
+		return nil, fmt.Errorf("connection to %s lost waiting for STAN %s", peerName, ticket)
+	case <-e.ctx.Done():
+		return nil, ErrEngineClosed
 	}
 }
 
-func predictResponseMTI(requestMTI string) string {
-	if len(requestMTI) != 4 {
-		return ""
-	}
-
-	// Convert to byte slice for easy manipulation
-	mti := []byte(requestMTI)
-
-	// ISO 8583 Logic:
-	// x0xx -> x1xx (Request -> Response)
-	// x2xx -> x3xx (Advice -> Advice Response)
-	// x4xx -> x5xx (Reversal -> Reversal Response)
-
-	if mti[2] == '0' || mti[2] == '2' || mti[2] == '4' {
-		mti[2] = mti[2] + 1
-	} else {
-		// Fallback: just force it to '1' as you did,
-		// which covers the most common 0100/0800 cases.
-		mti[2] = '1'
-	}
-
-	// Note: Some legacy hosts return 0110 for 0101 (Repeat).
-	// We force the 4th digit to '0' to match the response standard.
-	mti[3] = '0'
-
-	return string(mti)
-}
-
 func (e *Engine) createTicket(mti string, stan string) string {
 	// Trim spaces and ensure it's treated consistently
 	cleanStan := fmt.Sprintf("%06s", strings.TrimSpace(stan))
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"GoSwitch/pkg/iso8583"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// startEngine runs Start in the background, returning its result channel
+// once the engine accepts connections
+func startEngine(t *testing.T, e *Engine) <-chan error {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() { errc <- e.Start(context.Background()) }()
+	for range 100 {
+		if conn, err := net.Dial("tcp", e.Addr); err == nil {
+			conn.Close()
+			return errc
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("engine not listening")
+	return nil
+}
+
+// fakePeer answers every network management request, reporting its MTI
+func fakePeer(t *testing.T, spec *iso8583.Spec) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ch := NewNACChannel(conn, spec)
+		for {
+			msg, err := ch.Receive(conn)
+			if err != nil {
+				return
+			}
+			received <- msg.MTI
+			resp := echoRequest()
+			resp.MTI = "0810"
+			resp.Set(11, msg.Get(11))
+			ch.Send(resp)
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestEngineShutdown(t *testing.T) {
+	spec := loadSpec(t)
+	e := NewEngine(freeAddr(t), spec, NewNACChannel(nil, spec))
+	e.SignOff = func(peer *Peer) *iso8583.Message {
+		msg := echoRequest()
+		msg.Set(11, e.NextSTAN())
+		return msg
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	e.Request(func(c *Context) {
+		close(started)
+		<-release
+		resp := echoRequest()
+		resp.MTI = "0810"
+		c.Send(resp)
+	})
+
+	peerAddr, signOffs := fakePeer(t, spec)
+	e.Connect("peer", peerAddr)
+	errc := startEngine(t, e)
+	for range 100 {
+		if _, ok := e.peerSessions.Load("peer"); ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client, err := net.Dial("tcp", e.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ch := NewNACChannel(client, spec)
+	if err := ch.Send(echoRequest()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- e.Shutdown(ctx)
+	}()
+
+	// Start returns at once, while the handler in flight still holds Shutdown
+	if err := <-errc; !errors.Is(err, ErrEngineClosed) {
+		t.Fatalf("Start = %v", err)
+	}
+	if _, err := net.Dial("tcp", e.Addr); err == nil {
+		t.Error("connection accepted after Shutdown")
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown = %v before the handler returned", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	resp, err := ch.Receive(client)
+	if err != nil {
+		t.Fatalf("reply of the drained handler: %v", err)
+	}
+	if resp.MTI != "0810" {
+		t.Errorf("reply MTI = %q", resp.MTI)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if mti := <-signOffs; mti != "0800" {
+		t.Errorf("peer received %q, want sign-off", mti)
+	}
+}
+
+func TestEngineShutdownDeadline(t *testing.T) {
+	spec := loadSpec(t)
+	e := NewEngine(freeAddr(t), spec, NewNACChannel(nil, spec))
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	e.Request(func(c *Context) {
+		close(started)
+		<-c.Ctx.Done()
+		close(cancelled)
+	})
+	errc := startEngine(t, e)
+
+	client, err := net.Dial("tcp", e.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	NewNACChannel(client, spec).Send(echoRequest())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want deadline exceeded", err)
+	}
+	<-cancelled
+	<-errc
+}
+
+func TestContextCancelledOnConnectionLoss(t *testing.T) {
+	spec := loadSpec(t)
+	e := NewEngine("", spec, NewNACChannel(nil, spec))
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	e.Request(func(c *Context) {
+		close(started)
+		<-c.Ctx.Done()
+		close(cancelled)
+	})
+
+	client, conn := net.Pipe()
+	e.track(conn, true)
+	go e.serve(conn, &Listener{Name: "test", Channel: e.Channel, Spec: spec})
+
+	NewNACChannel(client, spec).Send(echoRequest())
+	<-started
+	client.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context not cancelled on connection loss")
+	}
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"GoSwitch/pkg/field"
+	"GoSwitch/pkg/iso8583"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Framing is the wire layout of a framed channel:
+//
+//	[length][header][ISO message][trailer]
+type Framing struct {
+	// Length encodes the frame length (e.g., &field.BinaryPrefix{Bytes: 2})
+	Length field.LengthPrefix
+	// Inclusive makes the length count its own bytes
+	Inclusive bool
+	// Header is a fixed header between length and message (e.g., a TPDU).
+	// As many bytes are split off received frames into Message.Header.
+	Header []byte
+	// SwapTPDU answers a received 5-byte TPDU with source and destination swapped
+	SwapTPDU bool
+	// Trailer follows every message (e.g., ETX 0x03); received trailers are discarded
+	Trailer []byte
+	// TrailerInLength makes the length count the trailer
+	TrailerInLength bool
+	// KeepAlive treats frames announcing no content as keep-alives: they are
+	// echoed back to the sender and never returned by Receive
+	KeepAlive bool
+}
+
+// FramedChannel is a Channel assembled from a Framing. The built-in NAC, NCC,
+// BCD and BASE24 channels are presets of it, and framings from app.yaml are
+// registered by name through RegisterFraming.
+//
+// Send is safe for concurrent use: every frame goes out in a single write,
+// one at a time. With QueueSize set, channels obtained through Clone hand
+// frames to a writer goroutine instead, Send blocking only while the queue
+// is full; write errors then surface on the next Send and on Close.
+// A write that fails or times out leaves a partial frame on the wire, so
+// every later write fails with the same error.
+type FramedChannel struct {
+	Name         string // Channel type, for logs
+	Conn         net.Conn
+	Spec         *iso8583.Spec
+	Framing      Framing
+	Debug        bool          // Log an annotated dump of every frame at Debug level (see iso8583.Dump)
+	QueueSize    int           // Outbound frames queued ahead of the writer goroutine; 0 for none
+	WriteTimeout time.Duration // Limit on every write, for peers that stop reading; 0 for none
+
+	wmu  sync.Mutex // Serializes writes to Conn and guards werr
+	werr error      // First write error
+
+	mu      sync.RWMutex // Held for reading while enqueuing, for writing by Close
+	closed  bool
+	queue   chan []byte
+	done    chan struct{} // Closed by Close
+	flushed chan struct{} // Closed when the writer goroutine exits
+}
+
+// ErrChannelClosed is returned by Send after Close
+var ErrChannelClosed = errors.New("channel is closed")
+
+func NewFramedChannel(name string, conn net.Conn, spec *iso8583.Spec, framing Framing) *FramedChannel {
+	return &FramedChannel{
+		Name:         name,
+		Conn:         conn,
+		Spec:         spec,
+		Framing:      framing,
+		WriteTimeout: DefaultWriteTimeout,
+	}
+}
+
+// DefaultWriteTimeout bounds the writes of the built-in channels
+const DefaultWriteTimeout = 30 * time.Second
+
+// RegisterFraming makes a framing available to NewChannel under name
+func RegisterFraming(name string, framing Framing) {
+	Register(name, func(conn net.Conn, spec *iso8583.Spec) Channel {
+		return NewFramedChannel(name, conn, spec, framing)
+	})
+}
+
+// frameLength is the length announced for a header and message of n bytes
+func (c *FramedChannel) frameLength(n int) int {
+	if c.Framing.TrailerInLength {
+		n += len(c.Framing.Trailer)
+	}
+	if c.Framing.Inclusive {
+		n += c.Framing.Length.Size()
+	}
+	return n
+}
+
+// ReadLength reads the length header, answering keep-alives on the way, and
+// returns the size of the header and message that follow
+func (c *FramedChannel) ReadLength(r io.Reader) (int, error) {
+	size := c.Framing.Length.Size()
+	header := make([]byte, size)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, err
+		}
+		length, _, err := c.Framing.Length.DecodeLength(header)
+		if err != nil {
+			return 0, err
+		}
+		if c.Framing.Inclusive {
+			length -= size
+		}
+
+		if length == 0 && c.Framing.KeepAlive {
+			if c.Conn != nil {
+				c.write(append([]byte(nil), header...))
+			}
+			continue
+		}
+
+		if c.Framing.TrailerInLength {
+			length -= len(c.Framing.Trailer)
+		}
+		if length < 0 {
+			return 0, fmt.Errorf("%s frame length % X is shorter than its framing", c.Name, header)
+		}
+		return length, nil
+	}
+}
+
+func (c *FramedChannel) WriteLength(w io.Writer, length int) error {
+	header, err := c.Framing.Length.EncodeLength(c.frameLength(length))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(header)
+	return err
+}
+
+func (c *FramedChannel) Receive(r io.Reader) (*iso8583.Message, error) {
+	length, err := c.ReadLength(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, length+len(c.Framing.Trailer))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	isoData := payload[:length]
+
+	var msgHeader []byte
+	if hLen := len(c.Framing.Header); hLen > 0 && length >= hLen {
+		msgHeader = isoData[:hLen]
+		isoData = isoData[hLen:]
+	}
+
+	if c.Debug {
+		logFrame(c.Name, "inbound", isoData, c.Spec)
+	}
+
+	msg := iso8583.NewMessage()
+	if err := msg.Unpack(isoData, c.Spec); err != nil {
+		return nil, err
+	}
+	// Kept so Send can answer with the same (or swapped) header
+	msg.SetHeader(msgHeader)
+
+	return msg, nil
+}
+
+// Send writes the whole frame with a single Write
+func (c *FramedChannel) Send(msg *iso8583.Message) error {
+	if c.Conn == nil {
+		return fmt.Errorf("%s channel has no connection", c.Name)
+	}
+
+	size := c.Framing.Length.Size()
+	header := c.header(msg)
+	// Room left for the length, filled in once the message is packed
+	frame := make([]byte, size, 512)
+	frame = append(frame, header...)
+
+	frame, err := msg.AppendPack(frame, c.Spec)
+	if err != nil {
+		return err
+	}
+	if c.Debug {
+		logFrame(c.Name, "outbound", frame[size+len(header):], c.Spec)
+	}
+
+	length, err := c.Framing.Length.EncodeLength(c.frameLength(len(frame) - size))
+	if err != nil {
+		return err
+	}
+	copy(frame, length)
+	frame = append(frame, c.Framing.Trailer...)
+
+	return c.write(frame)
+}
+
+// SendKeepAlive writes a frame with no content, for framings with KeepAlive set
+func (c *FramedChannel) SendKeepAlive() error {
+	if c.Conn == nil {
+		return fmt.Errorf("%s channel has no connection", c.Name)
+	}
+	length := 0
+	if c.Framing.Inclusive {
+		length = c.Framing.Length.Size()
+	}
+	header, err := c.Framing.Length.EncodeLength(length)
+	if err != nil {
+		return err
+	}
+	return c.write(header)
+}
+
+// write sends a whole frame, through the queue when there is one. The read
+// lock is held until the frame is queued, so that Close, which takes the
+// lock before stopping the writer, cannot leave it behind in the queue.
+// The writer drains the queue within WriteTimeout per frame.
+func (c *FramedChannel) write(frame []byte) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return fmt.Errorf("%s %w", c.Name, ErrChannelClosed)
+	}
+	if c.queue == nil {
+		return c.writeNow(frame)
+	}
+
+	c.wmu.Lock()
+	err := c.werr
+	c.wmu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.queue <- frame
+	return nil
+}
+
+// writeNow writes a frame to Conn within WriteTimeout, recording a failure
+func (c *FramedChannel) writeNow(frame []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.werr != nil {
+		return c.werr
+	}
+	if c.WriteTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	}
+	if _, err := c.Conn.Write(frame); err != nil {
+		c.werr = err
+		return err
+	}
+	return nil
+}
+
+// writer drains the queue until Close, then flushes what is left
+func (c *FramedChannel) writer() {
+	defer close(c.flushed)
+	for {
+		select {
+		case frame := <-c.queue:
+			c.writeNow(frame)
+		case <-c.done:
+			for {
+				select {
+				case frame := <-c.queue:
+					c.writeNow(frame)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close flushes the outbound queue and stops its writer goroutine; Send
+// fails from then on. Frames queued after a failed write are dropped, the
+// error being returned instead. The connection itself is left to its owner.
+func (c *FramedChannel) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	if c.queue != nil {
+		close(c.done)
+		<-c.flushed
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.werr
+}
+
+// header is the header sent before msg: the one it was received with,
+// TPDU-swapped if configured, else the fixed header of the framing
+func (c *FramedChannel) header(msg *iso8583.Message) []byte {
+	if len(c.Framing.Header) == 0 {
+		return nil
+	}
+	h := msg.GetHeader()
+	if len(h) != len(c.Framing.Header) {
+		return c.Framing.Header
+	}
+	if c.Framing.SwapTPDU && len(h) == 5 {
+		// Swap bytes 1-2 (destination) with 3-4 (source)
+		return []byte{h[0], h[3], h[4], h[1], h[2]}
+	}
+	return h
+}
+
+// Clone returns a channel for conn, starting its writer goroutine when
+// QueueSize is set
+func (c *FramedChannel) Clone(conn net.Conn) Channel {
+	clone := &FramedChannel{
+		Name:         c.Name,
+		Conn:         conn,
+		Spec:         c.Spec,
+		Framing:      c.Framing,
+		Debug:        c.Debug,
+		QueueSize:    c.QueueSize,
+		WriteTimeout: c.WriteTimeout,
+	}
+	if conn != nil && c.QueueSize > 0 {
+		clone.queue = make(chan []byte, c.QueueSize)
+		clone.done = make(chan struct{})
+		clone.flushed = make(chan struct{})
+		go clone.writer()
+	}
+	return clone
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"GoSwitch/pkg/iso8583"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+)
+
+func loadSpec(t testing.TB) *iso8583.Spec {
+	t.Helper()
+	spec, err := iso8583.LoadSpecFromFile("../../iso87binary.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func echoRequest() *iso8583.Message {
+	msg := iso8583.NewMessage()
+	msg.MTI = "0800"
+	msg.Set(11, "123456")
+	msg.Set(41, "TERM0001")
+	return msg
+}
+
+// sendFrame sends msg on a channel over one end of a pipe and returns the
+// bytes read off the other end
+func sendFrame(t *testing.T, ch *FramedChannel, msg *iso8583.Message) []byte {
+	t.Helper()
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ch = ch.Clone(client).(*FramedChannel)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ch.Send(msg)
+		client.Close()
+	}()
+	frame, _ := io.ReadAll(server)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func TestFramedChannelPresets(t *testing.T) {
+	spec := loadSpec(t)
+	packed, err := echoRequest().Pack(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(packed)
+
+	tests := []struct {
+		name    string
+		framing Framing
+		prefix  []byte
+		suffix  []byte
+	}{
+		{"NAC", NACFraming(), []byte{byte(n >> 8), byte(n)}, nil},
+		{"BCD", BCDFraming(), []byte{0x00, byte(n/10<<4 | n%10)}, nil},
+		{"BASE24", BASE24Framing(), []byte{0x00, byte(n + 1)}, []byte{0x03}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := NewFramedChannel(tt.name, nil, spec, tt.framing)
+			frame := sendFrame(t, ch, echoRequest())
+
+			want := append(append(append([]byte{}, tt.prefix...), packed...), tt.suffix...)
+			if !bytes.Equal(frame, want) {
+				t.Fatalf("frame = % X, want % X", frame, want)
+			}
+
+			msg, err := ch.Receive(bytes.NewReader(frame))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := msg.Get(11); got != "123456" {
+				t.Errorf("field 11 = %q", got)
+			}
+		})
+	}
+}
+
+func TestFramedChannelTPDU(t *testing.T) {
+	spec := loadSpec(t)
+	framing := NACFraming()
+	framing.Header = []byte{0x60, 0x00, 0x00, 0x00, 0x00}
+	ch := NewFramedChannel("NAC", nil, spec, framing)
+
+	packed, _ := echoRequest().Pack(spec)
+	in := append([]byte{0x00, byte(len(packed) + 5), 0x60, 0x00, 0x01, 0x00, 0x02}, packed...)
+	msg, err := ch.Receive(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := sendFrame(t, ch, msg)
+	if want := []byte{0x60, 0x00, 0x02, 0x00, 0x01}; !bytes.Equal(frame[2:7], want) {
+		t.Errorf("TPDU = % X, want % X", frame[2:7], want)
+	}
+
+	// Messages not received with a TPDU get the fixed one
+	frame = sendFrame(t, ch, echoRequest())
+	if !bytes.Equal(frame[2:7], framing.Header) {
+		t.Errorf("TPDU = % X, want % X", frame[2:7], framing.Header)
+	}
+}
+
+func TestFramedChannelKeepAlive(t *testing.T) {
+	spec := loadSpec(t)
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	ch := NewFramedChannel("BASE24", server, spec, BASE24Framing())
+
+	packed, _ := echoRequest().Pack(spec)
+	go func() {
+		client.Write([]byte{0x00, 0x00})
+		client.Write(append(append([]byte{0x00, byte(len(packed) + 1)}, packed...), 0x03))
+	}()
+
+	// The keep-alive is echoed while Receive waits for the message
+	echo := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 2)
+		io.ReadFull(client, buf)
+		echo <- buf
+	}()
+
+	msg, err := ch.Receive(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MTI != "0800" {
+		t.Errorf("MTI = %q", msg.MTI)
+	}
+	if got := <-echo; !bytes.Equal(got, []byte{0x00, 0x00}) {
+		t.Errorf("keep-alive echo = % X", got)
+	}
+}
+
+func TestNewFraming(t *testing.T) {
+	spec := loadSpec(t)
+	framing, err := NewFraming(config.FramingConfig{
+		Name:      "ASCII4",
+		Length:    config.LengthConfig{Type: "ascii", Digits: 4},
+		Inclusive: true,
+		Header:    "4142",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := sendFrame(t, NewFramedChannel("ASCII4", nil, spec, framing), echoRequest())
+	packed, _ := echoRequest().Pack(spec)
+	want := append([]byte(fmt.Sprintf("%04dAB", len(packed)+6)), packed...)
+	if !bytes.Equal(frame, want) {
+		t.Fatalf("frame = % X, want % X", frame, want)
+	}
+
+	if _, err := NewFraming(config.FramingConfig{Name: "bad", Length: config.LengthConfig{Type: "hex", Digits: 4}}); err == nil {
+		t.Error("unknown length type accepted")
+	}
+
+	// Framings from app.yaml become channel types, dumping frames if asked to
+	err = RegisterFramings([]config.FramingConfig{
+		{Name: "TEST_PLAIN", Length: config.LengthConfig{Type: "binary", Bytes: 2}},
+		{Name: "TEST_DEBUG", Length: config.LengthConfig{Type: "binary", Bytes: 2}, Debug: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"TEST_PLAIN": false, "TEST_DEBUG": true} {
+		ch, err := NewChannel(name, nil, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ch.(*FramedChannel).Debug; got != want {
+			t.Errorf("%s: debug = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// TestFrameDumpLevel checks that frame dumps go through slog at Debug level
+func TestFrameDumpLevel(t *testing.T) {
+	spec := loadSpec(t)
+	defer slog.SetDefault(slog.Default())
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo} {
+		var buf bytes.Buffer
+		slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})))
+
+		ch := NewNACChannel(nil, spec).(*FramedChannel)
+		ch.Debug = true
+		sendFrame(t, ch, echoRequest())
+
+		logged := strings.Contains(buf.String(), `msg="Frame dump"`)
+		if logged != (level == slog.LevelDebug) {
+			t.Errorf("level %v: frame dump logged = %v", level, logged)
+		}
+	}
+}
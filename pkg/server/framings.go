@@ -0,0 +1,113 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"GoSwitch/pkg/field"
+	"GoSwitch/pkg/iso8583"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// NACFraming: 2-byte binary length (big endian), TPDU swapped on replies.
+// Set Header to the TPDU some hosts expect (e.g., 6000000000).
+func NACFraming() Framing {
+	return Framing{Length: &field.BinaryPrefix{Bytes: 2}, SwapTPDU: true}
+}
+
+// NCCFraming: 2-byte BCD length, TPDU swapped on replies
+func NCCFraming() Framing {
+	return Framing{Length: &field.BCDPrefix{Digits: 4}, SwapTPDU: true}
+}
+
+// BCDFraming: 2-byte BCD length (e.g., 123 bytes = 0x01 0x23), TPDU swapped on replies
+func BCDFraming() Framing {
+	return Framing{Length: &field.BCDPrefix{Digits: 4}, SwapTPDU: true}
+}
+
+// BASE24Framing implements ACI's BASE24 TCP variant: 2-byte binary length
+// counting an ETX trailer, and zero-length keep-alives echoed back. Set
+// Header for a generic header (e.g., 10-byte ASCII).
+func BASE24Framing() Framing {
+	return Framing{
+		Length:          &field.BinaryPrefix{Bytes: 2},
+		Trailer:         []byte{0x03},
+		TrailerInLength: true,
+		KeepAlive:       true,
+	}
+}
+
+func NewNACChannel(conn net.Conn, spec *iso8583.Spec) Channel {
+	return NewFramedChannel("NAC", conn, spec, NACFraming())
+}
+
+func NewNCCChannel(conn net.Conn, spec *iso8583.Spec) Channel {
+	return NewFramedChannel("NCC", conn, spec, NCCFraming())
+}
+
+func NewBCDChannel(conn net.Conn, spec *iso8583.Spec) Channel {
+	return NewFramedChannel("BCD", conn, spec, BCDFraming())
+}
+
+func NewBASE24TCPChannel(conn net.Conn, spec *iso8583.Spec) Channel {
+	return NewFramedChannel("BASE24", conn, spec, BASE24Framing())
+}
+
+func init() {
+	Register("NAC", NewNACChannel)
+	Register("NCC", NewNCCChannel)
+	Register("BCD", NewBCDChannel)
+	Register("BASE24", NewBASE24TCPChannel)
+}
+
+// NewFraming builds a framing from its app.yaml definition
+func NewFraming(cfg config.FramingConfig) (Framing, error) {
+	size := cfg.Length.Digits
+	if cfg.Length.Type == "binary" {
+		size = cfg.Length.Bytes
+	}
+	length, err := field.NewLengthPrefix(cfg.Length.Type, size)
+	if err != nil {
+		return Framing{}, fmt.Errorf("framing %s: %v", cfg.Name, err)
+	}
+	header, err := hex.DecodeString(cfg.Header)
+	if err != nil {
+		return Framing{}, fmt.Errorf("framing %s: header: %v", cfg.Name, err)
+	}
+	trailer, err := hex.DecodeString(cfg.Trailer)
+	if err != nil {
+		return Framing{}, fmt.Errorf("framing %s: trailer: %v", cfg.Name, err)
+	}
+
+	return Framing{
+		Length:          length,
+		Inclusive:       cfg.Inclusive,
+		Header:          header,
+		SwapTPDU:        cfg.SwapTPDU,
+		Trailer:         trailer,
+		TrailerInLength: cfg.TrailerInLength,
+		KeepAlive:       cfg.KeepAlive,
+	}, nil
+}
+
+// RegisterFramings registers the framings defined in app.yaml as channel
+// types, next to (or in place of) the built-in ones; the channels of a
+// framing with debug set dump their frames
+func RegisterFramings(cfgs []config.FramingConfig) error {
+	for _, cfg := range cfgs {
+		if cfg.Name == "" {
+			return fmt.Errorf("framing without a name")
+		}
+		framing, err := NewFraming(cfg)
+		if err != nil {
+			return err
+		}
+		name, debug := cfg.Name, cfg.Debug
+		Register(name, func(conn net.Conn, spec *iso8583.Spec) Channel {
+			ch := NewFramedChannel(name, conn, spec, framing)
+			ch.Debug = debug
+			return ch
+		})
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"GoSwitch/pkg/iso8583"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Listener accepts incoming connections with its own channel, spec and handler
+type Listener struct {
+	Name        string
+	Addr        string
+	Channel     Channel       // Prototype, cloned for every connection
+	Spec        *iso8583.Spec // Spec of the messages received on it
+	ReadTimeout time.Duration // Connections silent for longer are closed; 0 for no limit
+	Handler     HandleFunc    // nil for the engine's Request handler
+}
+
+// NewListener builds a listener from its app.yaml definition, its handler
+// looked up by name among those registered with Engine.Handle
+func (e *Engine) NewListener(cfg config.ListenerConfig) (*Listener, error) {
+	channel, spec, err := configuredChannel(cfg.Channel, cfg.Spec, cfg.Header, cfg.QueueSize, cfg.Debug, e.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("listener %s: %v", cfg.Name, err)
+	}
+
+	var handler HandleFunc
+	if cfg.Handler != "" {
+		var ok bool
+		if handler, ok = e.handlers[cfg.Handler]; !ok {
+			return nil, fmt.Errorf("listener %s: unknown handler %s", cfg.Name, cfg.Handler)
+		}
+	}
+
+	return &Listener{
+		Name:        cfg.Name,
+		Addr:        net.JoinHostPort(cfg.IP, strconv.Itoa(cfg.Port)),
+		Channel:     channel,
+		Spec:        spec,
+		ReadTimeout: time.Duration(cfg.ReadTimeout) * time.Second,
+		Handler:     handler,
+	}, nil
+}
+
+// AddListeners adds the listeners of app.yaml; handlers they name must be
+// registered first
+func (e *Engine) AddListeners(cfgs []config.ListenerConfig) error {
+	for _, cfg := range cfgs {
+		l, err := e.NewListener(cfg)
+		if err != nil {
+			return err
+		}
+		e.Listen(l)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListeners(t *testing.T) {
+	spec := loadSpec(t)
+	e := NewEngine("", spec, NewNACChannel(nil, spec))
+	e.Request(func(c *Context) {
+		resp := echoRequest()
+		resp.MTI = "0810"
+		resp.Set(39, "00")
+		c.Send(resp)
+	})
+	e.Handle("atm", func(c *Context) {
+		resp := echoRequest()
+		resp.MTI = "0810"
+		resp.Set(39, "91")
+		c.Send(resp)
+	})
+
+	err := e.AddListeners([]config.ListenerConfig{
+		{Name: "POS", Channel: "NAC", Header: "6000000000", ReadTimeout: 5},
+		{Name: "ATM", Channel: "BASE24", Handler: "atm", Debug: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.listeners) != 2 || e.listeners[0].ReadTimeout != 5*time.Second {
+		t.Fatalf("listeners = %+v", e.listeners)
+	}
+	if e.listeners[0].Channel.(*FramedChannel).Debug || !e.listeners[1].Channel.(*FramedChannel).Debug {
+		t.Error("debug not taken from the listener config")
+	}
+
+	for _, tt := range []struct{ listener, code string }{{"POS", "00"}, {"ATM", "91"}} {
+		t.Run(tt.listener, func(t *testing.T) {
+			var l *Listener
+			for _, l = range e.listeners {
+				if l.Name == tt.listener {
+					break
+				}
+			}
+			client, conn := net.Pipe()
+			defer client.Close()
+			e.track(conn, true)
+			go e.serve(conn, l)
+
+			// The client speaks the listener's framing
+			ch := l.Channel.Clone(client)
+			if err := ch.Send(echoRequest()); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := ch.Receive(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Get(39); got != tt.code {
+				t.Errorf("field 39 = %q, want %q", got, tt.code)
+			}
+		})
+	}
+
+	if err := e.AddListeners([]config.ListenerConfig{{Name: "X", Handler: "missing"}}); err == nil {
+		t.Error("unknown handler accepted")
+	}
+}
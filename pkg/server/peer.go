@@ -0,0 +1,121 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"GoSwitch/pkg/iso8583"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Peer is an outgoing connection the engine keeps up, reconnecting with backoff
+type Peer struct {
+	Name    string
+	Addr    string
+	Channel Channel       // Prototype, cloned for every connection
+	Spec    *iso8583.Spec // Spec of the messages exchanged with the peer
+	Backoff Backoff
+}
+
+// Backoff is the delay between reconnect attempts: Initial after the first
+// failure, doubled after every further one up to Max, each delay varied at
+// random by up to Jitter (a fraction) so peers do not retry in lockstep
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Jitter  float64
+}
+
+// DefaultBackoff retries after 5s, then 10s, 20s... up to a minute, ±20%
+var DefaultBackoff = Backoff{Initial: 5 * time.Second, Max: time.Minute, Jitter: 0.2}
+
+// Delay returns the wait before reconnect attempt n (0 for the first retry).
+// A Backoff without Initial, as in a Peer built by hand, is DefaultBackoff;
+// one without Max grows up to DefaultBackoff.Max.
+func (b Backoff) Delay(n int) time.Duration {
+	if b.Initial <= 0 {
+		b = DefaultBackoff
+	}
+	if b.Max <= 0 {
+		b.Max = max(DefaultBackoff.Max, b.Initial)
+	}
+	d := b.Initial
+	for i := 0; i < n && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if b.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * b.Jitter * float64(d))
+	}
+	return d
+}
+
+// NewPeer builds a peer from its app.yaml definition; see configuredChannel
+// for its channel and spec
+func NewPeer(cfg config.ChannelConfig, spec *iso8583.Spec) (*Peer, error) {
+	channel, spec, err := configuredChannel(cfg.Type, cfg.Spec, cfg.Header, cfg.QueueSize, cfg.Debug, spec)
+	if err != nil {
+		return nil, fmt.Errorf("peer %s: %v", cfg.Name, err)
+	}
+
+	backoff := DefaultBackoff
+	if cfg.ReconnectInterval > 0 {
+		backoff.Initial = time.Duration(cfg.ReconnectInterval) * time.Second
+	}
+	if cfg.MaxReconnectInterval > 0 {
+		backoff.Max = time.Duration(cfg.MaxReconnectInterval) * time.Second
+	}
+	if backoff.Max < backoff.Initial {
+		backoff.Max = backoff.Initial
+	}
+
+	return &Peer{
+		Name:    cfg.Name,
+		Addr:    net.JoinHostPort(cfg.IP, strconv.Itoa(cfg.Port)),
+		Channel: channel,
+		Spec:    spec,
+		Backoff: backoff,
+	}, nil
+}
+
+// configuredChannel builds the channel of a peer or listener: its type comes
+// from the registry (NAC by default), its spec from specFile or else spec, a
+// header given in hex replaces the one of the framing, queueSize sets the
+// outbound queue of its sessions and debug turns on their frame dumps
+func configuredChannel(kind, specFile, header string, queueSize int, debug bool, spec *iso8583.Spec) (Channel, *iso8583.Spec, error) {
+	if specFile != "" {
+		var err error
+		if spec, err = iso8583.LoadSpecFromFile(specFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if kind == "" {
+		kind = "NAC"
+	}
+	channel, err := NewChannel(kind, nil, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header == "" && queueSize == 0 && !debug {
+		return channel, spec, nil
+	}
+	framed, ok := channel.(*FramedChannel)
+	if !ok {
+		return nil, nil, fmt.Errorf("channel type %s takes no header, queue or debug", kind)
+	}
+	if header != "" {
+		if framed.Framing.Header, err = hex.DecodeString(header); err != nil {
+			return nil, nil, fmt.Errorf("header: %v", err)
+		}
+	}
+	framed.QueueSize = queueSize
+	framed.Debug = framed.Debug || debug
+	return channel, spec, nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"GoSwitch/pkg/config"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for n, w := range want {
+		if got := b.Delay(n); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", n, got, w)
+		}
+	}
+
+	b.Jitter = 0.2
+	for range 100 {
+		if d := b.Delay(3); d < 6400*time.Millisecond || d > 9600*time.Millisecond {
+			t.Fatalf("Delay(3) = %v, outside 8s ±20%%", d)
+		}
+	}
+}
+
+func TestBackoffZeroValue(t *testing.T) {
+	// A hand-built Peer must not reconnect in a tight loop
+	var b Backoff
+	for n := range 10 {
+		if d := b.Delay(n); d < 4*time.Second || d > 72*time.Second {
+			t.Fatalf("Delay(%d) = %v, want DefaultBackoff", n, d)
+		}
+	}
+}
+
+func TestBackoffWithoutMax(t *testing.T) {
+	// Only the initial interval set, as with reconnect_interval alone
+	b := Backoff{Initial: 2 * time.Second}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute, time.Minute}
+	for n, w := range want {
+		if got := b.Delay(n); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", n, got, w)
+		}
+	}
+}
+
+func TestNewPeer(t *testing.T) {
+	spec := loadSpec(t)
+	peer, err := NewPeer(config.ChannelConfig{
+		Name:              "visa",
+		IP:                "10.1.1.5",
+		Port:              9000,
+		ReconnectInterval: 2,
+		Type:              "BASE24",
+		Header:            "6000010000",
+		Debug:             true,
+	}, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if peer.Addr != "10.1.1.5:9000" || peer.Spec != spec {
+		t.Errorf("peer = %+v", peer)
+	}
+	if peer.Backoff.Initial != 2*time.Second || peer.Backoff.Max != time.Minute {
+		t.Errorf("backoff = %+v", peer.Backoff)
+	}
+	framed := peer.Channel.(*FramedChannel)
+	if framed.Name != "BASE24" || !bytes.Equal(framed.Framing.Header, []byte{0x60, 0x00, 0x01, 0x00, 0x00}) || !framed.Debug {
+		t.Errorf("channel = %s, header % X, debug %v", framed.Name, framed.Framing.Header, framed.Debug)
+	}
+
+	if _, err := NewPeer(config.ChannelConfig{Name: "x", Type: "SNA"}, spec); err == nil {
+		t.Error("unknown channel type accepted")
+	}
+	if _, err := NewPeer(config.ChannelConfig{Name: "x", Spec: "missing.yaml"}, spec); err == nil {
+		t.Error("missing spec file accepted")
+	}
+}